@@ -0,0 +1,152 @@
+package rtfconverter
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+const (
+	// the longest reference: 4-bit length + 2
+	compressMaxMatch = 17
+
+	// the shortest reference; a shorter match is written as literal
+	compressMinMatch = 2
+
+	// how many previous positions are checked for the longest match
+	compressMaxChainLength = 256
+)
+
+/**
+ * Compress the RTF document in the compressed RTF format (MS-OXRTFCP, LZFu)
+ *
+ * The dictionary is pre-loaded with COMPRESSED_RTF_PREBUF, exactly like in Decompress, so the references
+ * may point in the pre-loaded bytes. The data ends with a self-reference (a reference to the current write position).
+ */
+func Compress(src []byte) ([]byte, error) {
+	var (
+		data      bytes.Buffer // the compressed data (without header)
+		run       [16]byte     // 8 literals/references of 1 or 2 bytes
+		runLength int
+		flags     byte
+		flagCount uint
+	)
+
+	// the decompressor simulates the dictionary with a linear buffer (pre-loaded bytes + uncompressed bytes); do the same
+	buf := make([]byte, 0, len(COMPRESSED_RTF_PREBUF)+len(src))
+	buf = append(buf, COMPRESSED_RTF_PREBUF...)
+	buf = append(buf, src...)
+
+	// hash chains of the positions starting with the same 2 bytes
+	head := make([]int32, 1<<16)
+	for i := range head {
+		head[i] = -1
+	}
+	prev := make([]int32, len(buf))
+
+	insert := func(pos int) {
+		if pos+1 < len(buf) {
+			key := int(buf[pos])<<8 | int(buf[pos+1])
+			prev[pos] = head[key]
+			head[key] = int32(pos)
+		}
+	}
+
+	// each flag byte controls 8 literals/references, 1 per bit; 1 for reference, 0 for literal
+	flush := func() {
+		data.WriteByte(flags)
+		data.Write(run[:runLength])
+		flags = 0
+		flagCount = 0
+		runLength = 0
+	}
+
+	writeReference := func(offset int, length int) {
+		// 12-bit offset (from block start) and 4-bit length
+		v := uint16(offset&DICT_MASK)<<4 | uint16(length-compressMinMatch)
+		binary.BigEndian.PutUint16(run[runLength:], v)
+		runLength += 2
+		flags |= 1 << flagCount
+		flagCount++
+	}
+
+	for pos := 0; pos < len(COMPRESSED_RTF_PREBUF); pos++ {
+		insert(pos)
+	}
+
+	pos := len(COMPRESSED_RTF_PREBUF)
+	for pos < len(buf) {
+		bestLength, bestOffset := 0, 0
+
+		if pos+1 < len(buf) {
+			chain := 0
+			for candidate := int(head[int(buf[pos])<<8|int(buf[pos+1])]); candidate >= 0 && chain < compressMaxChainLength; candidate = int(prev[candidate]) {
+				// a reference can't reach more than DICT_SIZE-1 bytes back: the current position marks the end of data
+				if pos-candidate >= DICT_SIZE {
+					break
+				}
+				chain++
+
+				// the referenced bytes can cross through the current position
+				length := 0
+				for length < compressMaxMatch && pos+length < len(buf) && buf[candidate+length] == buf[pos+length] {
+					length++
+				}
+				if length > bestLength {
+					bestLength, bestOffset = length, candidate
+					if length == compressMaxMatch {
+						break
+					}
+				}
+			}
+		}
+
+		if bestLength >= compressMinMatch {
+			writeReference(bestOffset, bestLength)
+			for i := 0; i < bestLength; i++ {
+				insert(pos + i)
+			}
+			pos += bestLength
+		} else {
+			run[runLength] = buf[pos]
+			runLength++
+			flagCount++
+			insert(pos)
+			pos++
+		}
+
+		if flagCount == 8 {
+			flush()
+		}
+	}
+
+	// a self-reference marks the end of data
+	writeReference(pos, compressMinMatch)
+	flush()
+
+	return compressedRtfHeader(MAGIC_COMPRESSED, len(src), data.Bytes()), nil
+}
+
+/**
+ * Wrap the RTF document in the uncompressed form of the compressed RTF format (MELA): the header followed by the raw data
+ */
+func CompressUncompressed(src []byte) ([]byte, error) {
+	return compressedRtfHeader(MAGIC_UNCOMPRESSED, len(src), src), nil
+}
+
+/**
+ * header: compressed size (excluding the size field), raw size, compression type, CRC32 of the compressed data;
+ * the CRC is 0 for the uncompressed form
+ */
+func compressedRtfHeader(magic int, rawSize int, data []byte) []byte {
+	result := make([]byte, 16+len(data))
+
+	binary.LittleEndian.PutUint32(result[0:], uint32(len(result)-4))
+	binary.LittleEndian.PutUint32(result[4:], uint32(rawSize))
+	binary.LittleEndian.PutUint32(result[8:], uint32(magic))
+	if magic == MAGIC_COMPRESSED {
+		binary.LittleEndian.PutUint32(result[12:], uint32(calculateCRC32(data, 0, len(data))))
+	}
+	copy(result[16:], data)
+
+	return result
+}
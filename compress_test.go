@@ -0,0 +1,96 @@
+package rtfconverter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func checkCompressedRtfHeader(t *testing.T, compressed []byte, magic int, rawSize int) {
+	t.Helper()
+
+	if len(compressed) < 16 {
+		t.Fatalf("header too short: %d bytes", len(compressed))
+	}
+	if got := int(getU32(compressed, 0)); got != len(compressed)-4 {
+		t.Fatalf("compSize %d, expected %d", got, len(compressed)-4)
+	}
+	if got := int(getU32(compressed, 4)); got != rawSize {
+		t.Fatalf("rawSize %d, expected %d", got, rawSize)
+	}
+	if got := int(getU32(compressed, 8)); got != magic {
+		t.Fatalf("magic %#x, expected %#x", got, magic)
+	}
+
+	crc := 0
+	if magic == MAGIC_COMPRESSED {
+		crc = calculateCRC32(compressed, 16, len(compressed)-16)
+	}
+	if got := int(getU32(compressed, 12)); got != crc {
+		t.Fatalf("CRC %#x, expected %#x", got, crc)
+	}
+}
+
+func TestCompressRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"empty", ""},
+		{"short", "{\\rtf1 hello}"},
+		{"repetitive", strings.Repeat("{\\rtf1\\ansi\\pard abcdefgh\\par}", 400)},
+		{"prebuf", COMPRESSED_RTF_PREBUF},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src := []byte(test.src)
+
+			compressed, err := Compress(src)
+			if err != nil {
+				t.Fatalf("compress failed: %v", err)
+			}
+			checkCompressedRtfHeader(t, compressed, MAGIC_COMPRESSED, len(src))
+
+			out, err := Decompress(compressed)
+			if err != nil {
+				t.Fatalf("decompress failed: %v", err)
+			}
+			if !bytes.Equal(out, src) {
+				t.Fatalf("round trip mismatch: got %d bytes, expected %d bytes", len(out), len(src))
+			}
+		})
+	}
+
+	// the repetitive input is larger than the dictionary and must be compressed
+	src := []byte(tests[2].src)
+	if len(src) <= DICT_SIZE {
+		t.Fatalf("repetitive input has only %d bytes", len(src))
+	}
+	if compressed, _ := Compress(src); len(compressed) >= len(src)/4 {
+		t.Fatalf("repetitive input compressed to %d bytes from %d bytes", len(compressed), len(src))
+	}
+
+	// the pre-loaded dictionary is referenced: the compressed data is smaller than the input
+	if compressed, _ := Compress([]byte(COMPRESSED_RTF_PREBUF)); len(compressed)-16 >= len(COMPRESSED_RTF_PREBUF)/4 {
+		t.Fatalf("pre-loaded dictionary not referenced: %d compressed bytes", len(compressed)-16)
+	}
+}
+
+func TestCompressUncompressedRoundTrip(t *testing.T) {
+	src := []byte("{\\rtf1\\ansi\\pard hello\\par}")
+
+	compressed, err := CompressUncompressed(src)
+	if err != nil {
+		t.Fatalf("compress failed: %v", err)
+	}
+	checkCompressedRtfHeader(t, compressed, MAGIC_UNCOMPRESSED, len(src))
+
+	out, err := Decompress(compressed)
+	if err != nil {
+		t.Fatalf("decompress failed: %v", err)
+	}
+	if !bytes.Equal(out, src) {
+		t.Fatalf("round trip mismatch: %q", out)
+	}
+}
@@ -5,7 +5,7 @@
 package rtfconverter
 
 import (
-	"errors"
+	"io"
 	"io/ioutil"
 //	"fmt"
 )
@@ -14,35 +14,131 @@ type RtfInterpreter interface {
 	Parse(rtfObj RtfStructure) ([]byte, error)
 }
 
+/**
+ * an interpreter that writes the result to a writer while the document is converted
+ */
+type RtfWriterInterpreter interface {
+	ParseTo(w io.Writer, rtfObj RtfStructure) error
+}
+
+/**
+ * an interpreter that converts the document while it is read, without building the RTF tree
+ */
+type RtfStreamInterpreter interface {
+	ParseReader(reader io.Reader) ([]byte, error)
+	ParseReaderTo(w io.Writer, reader io.Reader) error
+}
+
 
 type rtfConverter struct {
 	rtfObj RtfStructure
+
+	// the error returned when the document was loaded; the document can't be converted
+	loadErr error
+
+	// the options of the loading and of the conversions
+	options rtfOptions
 }
 
 
 
 /**
- * create a new convertor
+ * create a new convertor; the options configure the loading and the conversions of the documents
  */
 
-func NewConverter() (rtfConverter) {
-	c := rtfConverter{}
+func NewConverter(opts ...Option) (rtfConverter) {
+	c := rtfConverter{options: newRtfOptions(opts)}
 	return c;
 }
 
 
-func (c *rtfConverter) LoadFile(sourceFile string) {
-	c.rtfObj = RtfStructure{}
+func (c *rtfConverter) LoadFile(sourceFile string) (error) {
+	c.rtfObj = c.options.newStructure()
 
 	// decompose RTF into structure based on words, symbols, etc
-	c.rtfObj.ParseFile(sourceFile)
+	c.loadErr = c.rtfObj.ParseFile(sourceFile)
+
+	return c.loadErr
 }
 
-func (c *rtfConverter) SetBytes(content []byte)  {
-	c.rtfObj = RtfStructure{}
+func (c *rtfConverter) SetBytes(content []byte) (error) {
+	c.rtfObj = c.options.newStructure()
 
 	// decompose RTF into structure based on words, symbols, etc
-	c.rtfObj.ParseBytes(content)
+	c.loadErr = c.rtfObj.ParseBytes(content)
+
+	return c.loadErr
+}
+
+func (c *rtfConverter) LoadReader(reader io.Reader) (error) {
+	c.rtfObj = c.options.newStructure()
+
+	// decompose RTF into structure based on words, symbols, etc
+	c.loadErr = c.rtfObj.ParseReader(reader)
+
+	return c.loadErr
+}
+
+/**
+ * the pictures of the loaded document
+ */
+func (c *rtfConverter) Pictures() ([]RtfPicture, error) {
+	if c.loadErr != nil {
+		return nil, c.loadErr
+	}
+
+	return c.rtfObj.Pictures()
+}
+
+/**
+ * the objects (OLE objects and attached files) of the loaded document
+ */
+func (c *rtfConverter) Objects() ([]RtfObject, error) {
+	if c.loadErr != nil {
+		return nil, c.loadErr
+	}
+
+	return c.rtfObj.Objects()
+}
+
+/**
+ * the fields (hyperlinks, page numbers, dates, etc) of the loaded document
+ */
+func (c *rtfConverter) Fields() ([]RtfField, error) {
+	if c.loadErr != nil {
+		return nil, c.loadErr
+	}
+
+	return c.rtfObj.Fields()
+}
+
+/**
+ * the styles (\stylesheet) of the loaded document
+ */
+func (c *rtfConverter) Styles() ([]RtfStyle, error) {
+	if c.loadErr != nil {
+		return nil, c.loadErr
+	}
+
+	return c.rtfObj.Styles()
+}
+
+/**
+ * the metadata (\info group) of the loaded document
+ */
+func (c *rtfConverter) Metadata() (RtfMetadata, error) {
+	if c.loadErr != nil {
+		return RtfMetadata{}, c.loadErr
+	}
+
+	return c.rtfObj.Metadata()
+}
+
+/**
+ * detect the format the loaded document was produced from
+ */
+func (c *rtfConverter) DetectEncapsulation() (RtfEncapsulation) {
+	return c.rtfObj.DetectEncapsulation()
 }
 
 func (c *rtfConverter) SaveFile(content []byte, path string) (error) {
@@ -57,6 +153,10 @@ func (c *rtfConverter) Convert(exportType string) (result []byte, err error) {
 		parser RtfInterpreter
 	)
 
+	if c.loadErr != nil {
+		return nil, c.loadErr
+	}
+
 	parser, err = c.getInterpreter(exportType)
 
 	if err != nil {
@@ -72,13 +172,79 @@ func (c *rtfConverter) Convert(exportType string) (result []byte, err error) {
 	return result, nil
 }
 
+/**
+ * convert the loaded document and write the result to w while it is converted
+ */
+func (c *rtfConverter) ConvertTo(w io.Writer, exportType string) (error) {
+	if c.loadErr != nil {
+		return c.loadErr
+	}
+
+	parser, err := c.getInterpreter(exportType)
+
+	if err != nil {
+		return err
+	}
+
+	writerParser, ok := parser.(RtfWriterInterpreter)
+	if !ok {
+		return ErrUnsupportedFormat
+	}
+
+	return writerParser.ParseTo(w, c.rtfObj)
+}
+
+/**
+ * convert the document while it is read from the reader; the RTF tree is not built, so large documents
+ * are converted with bounded memory (the loaded document is not used)
+ */
+func (c *rtfConverter) ConvertReader(reader io.Reader, exportType string) (result []byte, err error) {
+	parser, err := c.getInterpreter(exportType)
+
+	if err != nil {
+		return result, err
+	}
+
+	streamParser, ok := parser.(RtfStreamInterpreter)
+	if !ok {
+		return nil, ErrUnsupportedFormat
+	}
+
+	return streamParser.ParseReader(reader)
+}
+
+/**
+ * convert the document while it is read from the reader and write the result to w while it is converted
+ */
+func (c *rtfConverter) ConvertReaderTo(w io.Writer, reader io.Reader, exportType string) (error) {
+	parser, err := c.getInterpreter(exportType)
+
+	if err != nil {
+		return err
+	}
+
+	streamParser, ok := parser.(RtfStreamInterpreter)
+	if !ok {
+		return ErrUnsupportedFormat
+	}
+
+	return streamParser.ParseReaderTo(w, reader)
+}
+
+/**
+ * the interpreter of the format, configured with the converter options; an option that is not valid is returned as error
+ */
 func (c *rtfConverter) getInterpreter(interpreterType string) (RtfInterpreter, error) {
+	if c.options.err != nil {
+		return nil, c.options.err
+	}
+
 	switch interpreterType {
 	case "html":
-		return &rtfHtmlInterpreter{}, nil
+		return &rtfHtmlInterpreter{options: c.options}, nil
 	case "text":
-		return &rtfTextInterpreter{}, nil
+		return &rtfTextInterpreter{options: c.options}, nil
 	default:
-		return nil, errors.New("Parser for conversion do not exists.")
+		return nil, ErrUnsupportedFormat
 	}
 }
@@ -0,0 +1,185 @@
+package rtfconverter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+/**
+ * a reader that counts the bytes read
+ */
+type countingReader struct {
+	reader io.Reader
+	read   int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += n
+	return n, err
+}
+
+/**
+ * a writer that records how many bytes of the source were read when the first output was written
+ */
+type firstWriteRecorder struct {
+	source    *countingReader
+	readFirst int
+	output    bytes.Buffer
+}
+
+func (w *firstWriteRecorder) Write(p []byte) (int, error) {
+	if w.output.Len() == 0 && len(p) > 0 {
+		w.readFirst = w.source.read
+	}
+	return w.output.Write(p)
+}
+
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
+
+type failingReader struct {
+	data []byte
+	err  error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, r.err
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestConvertToOutput(t *testing.T) {
+	documents := map[string]string{
+		"native": "{\\rtf1\\ansi{\\fonttbl{\\f0 Arial;}}\\pard\\b bold\\b0  <text> & more\\par" +
+			"\\trowd\\cellx1000\\cellx2000 a\\cell b\\cell\\row\\pard\\ls1 item\\par}",
+		"html": "{\\rtf1\\ansi\\fromhtml1 {\\*\\htmltag64 <p>}hello{\\*\\htmltag84 &amp;} world{\\*\\htmltag72 </p>}}",
+		"text": "{\\rtf1\\ansi\\fromtext hello\\par world\\line end}",
+	}
+
+	for name, rtf := range documents {
+		for _, format := range []string{"html", "text"} {
+			t.Run(name+" to "+format, func(t *testing.T) {
+				c := NewConverter()
+				if err := c.SetBytes([]byte(rtf)); err != nil {
+					t.Fatalf("load failed: %v", err)
+				}
+				expected, err := c.Convert(format)
+				if err != nil {
+					t.Fatalf("conversion failed: %v", err)
+				}
+
+				var output bytes.Buffer
+				if err := c.ConvertTo(&output, format); err != nil {
+					t.Fatalf("ConvertTo failed: %v", err)
+				}
+				if output.String() != string(expected) {
+					t.Fatalf("ConvertTo wrote %q, expected %q", output.String(), expected)
+				}
+
+				streamed, err := c.ConvertReader(strings.NewReader(rtf), format)
+				if err != nil {
+					t.Fatalf("ConvertReader failed: %v", err)
+				}
+				if string(streamed) != string(expected) {
+					t.Fatalf("ConvertReader returned %q, expected %q", streamed, expected)
+				}
+
+				output.Reset()
+				if err := c.ConvertReaderTo(&output, strings.NewReader(rtf), format); err != nil {
+					t.Fatalf("ConvertReaderTo failed: %v", err)
+				}
+				if output.String() != string(expected) {
+					t.Fatalf("ConvertReaderTo wrote %q, expected %q", output.String(), expected)
+				}
+			})
+		}
+	}
+}
+
+func TestConvertReaderToStreams(t *testing.T) {
+	doc := strings.Builder{}
+	doc.WriteString("{\\rtf1\\ansi ")
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&doc, "\\pard paragraph %d\\par\n", i)
+	}
+	doc.WriteString("}")
+
+	for _, format := range []string{"html", "text"} {
+		t.Run(format, func(t *testing.T) {
+			source := &countingReader{reader: strings.NewReader(doc.String())}
+			output := &firstWriteRecorder{source: source}
+
+			c := NewConverter()
+			if err := c.ConvertReaderTo(output, source, format); err != nil {
+				t.Fatalf("ConvertReaderTo failed: %v", err)
+			}
+
+			// the output is written before the end of the document is read
+			if output.readFirst == 0 || output.readFirst >= doc.Len()/2 {
+				t.Fatalf("the first output was written after %d of %d bytes were read", output.readFirst, doc.Len())
+			}
+			if !strings.Contains(output.output.String(), "paragraph 4999") {
+				t.Fatalf("the last paragraph was not written")
+			}
+		})
+	}
+}
+
+func TestConvertToErrors(t *testing.T) {
+	rtf := "{\\rtf1 text\\par}"
+
+	c := NewConverter()
+	if err := c.SetBytes([]byte(rtf)); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if err := c.ConvertTo(io.Discard, "pdf"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("ConvertTo: expected %v, got %v", ErrUnsupportedFormat, err)
+	}
+	if err := c.ConvertReaderTo(io.Discard, strings.NewReader(rtf), "pdf"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("ConvertReaderTo: expected %v, got %v", ErrUnsupportedFormat, err)
+	}
+
+	// the error of the writer stops the conversion
+	writeErr := errors.New("disk full")
+	for _, format := range []string{"html", "text"} {
+		if err := c.ConvertTo(failingWriter{writeErr}, format); !errors.Is(err, writeErr) {
+			t.Fatalf("ConvertTo %s: expected %v, got %v", format, writeErr, err)
+		}
+		if err := c.ConvertReaderTo(failingWriter{writeErr}, strings.NewReader(rtf), format); !errors.Is(err, writeErr) {
+			t.Fatalf("ConvertReaderTo %s: expected %v, got %v", format, writeErr, err)
+		}
+	}
+
+	// the error of the reader stops the conversion
+	readErr := errors.New("connection reset")
+	for _, format := range []string{"html", "text"} {
+		reader := &failingReader{data: []byte("{\\rtf1 text\\par more"), err: readErr}
+		if err := c.ConvertReaderTo(io.Discard, reader, format); !errors.Is(err, readErr) {
+			t.Fatalf("ConvertReaderTo %s: expected %v, got %v", format, readErr, err)
+		}
+	}
+
+	// the load error is returned by the conversions of the loaded document
+	if err := c.SetBytes([]byte("{\\rtf1{\\b text}")); !errors.Is(err, ErrUnbalancedGroups) {
+		t.Fatalf("load: expected %v, got %v", ErrUnbalancedGroups, err)
+	}
+	if err := c.ConvertTo(io.Discard, "html"); !errors.Is(err, ErrUnbalancedGroups) {
+		t.Fatalf("ConvertTo: expected %v, got %v", ErrUnbalancedGroups, err)
+	}
+	if err := c.ConvertReaderTo(io.Discard, strings.NewReader("{\\rtf1{\\b text}"), "html"); !errors.Is(err, ErrUnbalancedGroups) {
+		t.Fatalf("ConvertReaderTo: expected %v, got %v", ErrUnbalancedGroups, err)
+	}
+}
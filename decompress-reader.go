@@ -0,0 +1,267 @@
+package rtfconverter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+/**
+ * streaming decompressor of the compressed RTF format (MS-OXRTFCP)
+ *
+ * The compressed data is read only when the uncompressed bytes are requested and the references are resolved
+ * in a 4096 bytes ring dictionary, so neither the compressed nor the uncompressed document is kept in memory.
+ * The CRC is validated when the end of the compressed data is reached.
+ */
+type decompressReader struct {
+	src *bufio.Reader
+
+	headerRead bool
+	magic      int
+	rawSize    int
+	crc32sum   int
+
+	// compressed bytes not read yet (including the padding after the end of data)
+	remaining int
+
+	// CRC of the compressed bytes read
+	crc int
+
+	dict     [DICT_SIZE]byte
+	writePos int
+
+	// the current flag byte: each flag byte controls 8 literals/references, 1 per bit
+	flags     int
+	flagCount int
+
+	// uncompressed bytes not returned yet
+	pending []byte
+
+	// the uncompressed bytes returned, and the maximum allowed (0 - no limit)
+	produced int
+	maxSize  int
+
+	done bool
+	err  error
+}
+
+/**
+ * return a reader of the uncompressed RTF document; the result can be parsed directly (eg: RtfStructure.ParseReader)
+ * the uncompressed document can not be larger than DefaultMaxDecompressedSize
+ */
+func NewDecompressReader(r io.Reader) io.Reader {
+	return NewDecompressReaderWithLimit(r, DefaultMaxDecompressedSize)
+}
+
+/**
+ * return a reader of the uncompressed RTF document, that can not be larger than maxSize bytes (0 - no limit)
+ */
+func NewDecompressReaderWithLimit(r io.Reader, maxSize int) io.Reader {
+	return &decompressReader{src: bufio.NewReader(r), maxSize: maxSize}
+}
+
+func (d *decompressReader) Read(p []byte) (int, error) {
+	if !d.headerRead {
+		if err := d.readHeader(); err != nil {
+			d.err = err
+		}
+	}
+
+	n := 0
+	for n < len(p) {
+		if len(d.pending) > 0 {
+			if d.maxSize > 0 && d.produced+len(d.pending) > d.maxSize {
+				d.pending = d.pending[:d.maxSize-d.produced]
+				d.err = ErrDecompressedSizeExceeded
+			}
+
+			c := copy(p[n:], d.pending)
+			d.pending = d.pending[c:]
+			d.produced += c
+			n += c
+			continue
+		}
+
+		if d.err != nil || d.done {
+			break
+		}
+
+		if d.magic == MAGIC_UNCOMPRESSED {
+			d.readRaw(p[n:], &n)
+			if d.maxSize > 0 && d.produced > d.maxSize {
+				n -= d.produced - d.maxSize
+				d.produced = d.maxSize
+				d.err = ErrDecompressedSizeExceeded
+			}
+		} else {
+			d.decodeNext()
+		}
+	}
+
+	if n > 0 {
+		return n, nil
+	}
+	if d.err != nil {
+		return 0, d.err
+	}
+	return 0, io.EOF
+}
+
+/**
+ * header: compressed size (excluding the size field), raw size, compression type, CRC32 of the compressed data
+ */
+func (d *decompressReader) readHeader() error {
+	d.headerRead = true
+
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(d.src, header); err != nil {
+		return fmt.Errorf("%w: invalid header", ErrInvalidCompressedRTF)
+	}
+
+	compressedSize := int(getU32(header, 0))
+	d.rawSize = int(getU32(header, 4))
+	d.magic = int(getU32(header, 8))
+	d.crc32sum = int(getU32(header, 12))
+
+	if compressedSize < 12 {
+		return fmt.Errorf("%w: compressed data size mismatch", ErrInvalidCompressedRTF)
+	}
+	d.remaining = compressedSize - 12
+
+	switch d.magic {
+	case MAGIC_UNCOMPRESSED:
+		if d.rawSize > d.remaining {
+			return fmt.Errorf("%w: uncompressed data size mismatch", ErrInvalidCompressedRTF)
+		}
+		d.remaining = d.rawSize
+	case MAGIC_COMPRESSED:
+		d.writePos = copy(d.dict[:], COMPRESSED_RTF_PREBUF)
+	default:
+		return fmt.Errorf("%w: unknown compression type (magic number %#x)", ErrInvalidCompressedRTF, d.magic)
+	}
+
+	return nil
+}
+
+/**
+ * the uncompressed form: the raw data follows the header
+ */
+func (d *decompressReader) readRaw(p []byte, n *int) {
+	if d.remaining == 0 {
+		d.done = true
+		return
+	}
+
+	if len(p) > d.remaining {
+		p = p[:d.remaining]
+	}
+	c, err := d.src.Read(p)
+	d.remaining -= c
+	d.produced += c
+	*n += c
+
+	if err == io.EOF && d.remaining > 0 {
+		d.err = ErrTruncatedCompressedRTF
+	} else if err != nil && err != io.EOF {
+		d.err = err
+	}
+}
+
+/**
+ * read a compressed byte and continue the CRC calculation
+ */
+func (d *decompressReader) readByte() (byte, error) {
+	if d.remaining <= 0 {
+		// the self-reference was not found
+		return 0, ErrTruncatedCompressedRTF
+	}
+
+	b, err := d.src.ReadByte()
+	if err == io.EOF {
+		return 0, ErrTruncatedCompressedRTF
+	} else if err != nil {
+		return 0, err
+	}
+
+	d.remaining--
+	d.crc = CRC32_TABLE[(d.crc^int(b))&0xFF] ^ shiftZeroLeft(d.crc, 8)
+	return b, nil
+}
+
+/**
+ * decode the next literal or reference to the pending bytes
+ */
+func (d *decompressReader) decodeNext() {
+	if (d.flagCount & 7) == 0 {
+		b, err := d.readByte()
+		if err != nil {
+			d.err = err
+			return
+		}
+		d.flags = int(b)
+	} else {
+		d.flags = d.flags >> 1
+	}
+	d.flagCount++
+
+	if (d.flags & 1) == 0 {
+		b, err := d.readByte()
+		if err != nil {
+			d.err = err
+			return
+		}
+		d.dict[d.writePos] = b
+		d.writePos = (d.writePos + 1) & DICT_MASK
+		d.pending = append(d.pending[:0], b)
+		return
+	}
+
+	// Read reference: 12-bit offset (from block start) and 4-bit length
+	b0, err := d.readByte()
+	if err != nil {
+		d.err = err
+		return
+	}
+	b1, err := d.readByte()
+	if err != nil {
+		d.err = err
+		return
+	}
+
+	offset := int(b0)<<4 | int(b1)>>4
+	length := int(b1&0xF) + 2
+
+	if offset == d.writePos {
+		// a self-reference marks the end of data
+		d.finish()
+		return
+	}
+
+	// the referenced bytes can cross through the current write position
+	d.pending = d.pending[:0]
+	for i := 0; i < length; i++ {
+		b := d.dict[(offset+i)&DICT_MASK]
+		d.dict[d.writePos] = b
+		d.writePos = (d.writePos + 1) & DICT_MASK
+		d.pending = append(d.pending, b)
+	}
+}
+
+/**
+ * the end of data was reached; the padding is included in the CRC
+ */
+func (d *decompressReader) finish() {
+	for d.remaining > 0 {
+		if _, err := d.readByte(); err != nil {
+			d.err = err
+			return
+		}
+	}
+
+	if d.crc != d.crc32sum {
+		d.err = fmt.Errorf("%w: CRC32 failed", ErrInvalidCompressedRTF)
+		return
+	}
+
+	d.done = true
+}
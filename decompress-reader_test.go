@@ -0,0 +1,137 @@
+package rtfconverter
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+/**
+ * read the whole stream with a small buffer, from a source that returns one byte per read
+ */
+func readDecompressed(src []byte, maxSize int) ([]byte, error) {
+	reader := NewDecompressReaderWithLimit(iotest.OneByteReader(bytes.NewReader(src)), maxSize)
+
+	var (
+		out []byte
+		buf [7]byte
+	)
+	for {
+		n, err := reader.Read(buf[:])
+		out = append(out, buf[:n]...)
+		if err == io.EOF {
+			return out, nil
+		} else if err != nil {
+			return out, err
+		}
+	}
+}
+
+func TestDecompressReaderMatchesDecompress(t *testing.T) {
+	inputs := decompressSeeds()
+
+	for name, src := range map[string]string{
+		"empty":      "",
+		"repetitive": strings.Repeat("{\\rtf1\\ansi\\pard abcdefgh\\par}", 400),
+		"prebuf":     COMPRESSED_RTF_PREBUF,
+	} {
+		inputs["compressed "+name], _ = Compress([]byte(src))
+		inputs["uncompressed "+name], _ = CompressUncompressed([]byte(src))
+	}
+
+	valid := inputs["compressed repetitive"]
+
+	badCrc := append([]byte(nil), valid...)
+	badCrc[len(badCrc)-1] ^= 0xff
+	inputs["bad crc"] = badCrc
+	inputs["truncated"] = valid[:len(valid)-3]
+	inputs["truncated uncompressed"] = inputs["uncompressed repetitive"][:100]
+
+	for name, src := range inputs {
+		for _, maxSize := range []int{0, 100} {
+			out, err := DecompressWithLimit(src, maxSize)
+			streamed, streamErr := readDecompressed(src, maxSize)
+
+			if err == nil {
+				if streamErr != nil {
+					t.Fatalf("%s, limit %d: the reader failed: %v", name, maxSize, streamErr)
+				}
+				if !bytes.Equal(out, streamed) {
+					t.Fatalf("%s, limit %d: the reader returned %d bytes, expected %d bytes", name, maxSize, len(streamed), len(out))
+				}
+				continue
+			}
+
+			if streamErr == nil {
+				t.Fatalf("%s, limit %d: Decompress failed with %v, the reader did not fail", name, maxSize, err)
+			}
+			if maxSize > 0 && errors.Is(streamErr, ErrDecompressedSizeExceeded) {
+				// the reader validates the CRC at the end of data, so it may reach the limit first
+				continue
+			}
+			for _, target := range []error{ErrInvalidCompressedRTF, ErrDecompressedSizeExceeded} {
+				if errors.Is(err, target) != errors.Is(streamErr, target) {
+					t.Fatalf("%s, limit %d: Decompress failed with %v, the reader with %v", name, maxSize, err, streamErr)
+				}
+			}
+		}
+	}
+}
+
+func TestDecompressReaderLimit(t *testing.T) {
+	src := []byte(strings.Repeat("{\\rtf1\\ansi\\pard abcdefgh\\par}", 400))
+
+	for _, form := range []string{"compressed", "uncompressed"} {
+		var compressed []byte
+		if form == "compressed" {
+			compressed, _ = Compress(src)
+		} else {
+			compressed, _ = CompressUncompressed(src)
+		}
+
+		// the bytes up to the limit are returned before the error
+		streamed, err := readDecompressed(compressed, 1000)
+		if !errors.Is(err, ErrDecompressedSizeExceeded) {
+			t.Fatalf("%s: expected %v, got %v", form, ErrDecompressedSizeExceeded, err)
+		}
+		if !bytes.Equal(streamed, src[:1000]) {
+			t.Fatalf("%s: got %d bytes before the error", form, len(streamed))
+		}
+
+		// the data has exactly the maximum size
+		if streamed, err := readDecompressed(compressed, len(src)); err != nil || !bytes.Equal(streamed, src) {
+			t.Fatalf("%s: got %d bytes, %v", form, len(streamed), err)
+		}
+	}
+}
+
+func TestDecompressReaderErrors(t *testing.T) {
+	valid, _ := Compress([]byte("{\\rtf1 hello}"))
+
+	// the error of the source is returned
+	readErr := errors.New("read failed")
+	reader := NewDecompressReader(io.MultiReader(bytes.NewReader(valid[:20]), iotest.ErrReader(readErr)))
+	if _, err := io.ReadAll(reader); !errors.Is(err, readErr) {
+		t.Fatalf("expected %v, got %v", readErr, err)
+	}
+
+	// the error is returned again by the next reads
+	reader = NewDecompressReader(bytes.NewReader(valid[:10]))
+	for i := 0; i < 2; i++ {
+		if _, err := reader.Read(make([]byte, 10)); !errors.Is(err, ErrInvalidCompressedRTF) {
+			t.Fatalf("read %d: expected %v, got %v", i, ErrInvalidCompressedRTF, err)
+		}
+	}
+
+	// the stream can be parsed directly
+	var rtfObj RtfStructure
+	if err := rtfObj.ParseReader(NewDecompressReader(bytes.NewReader(valid))); err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if text := string(rtfObj.Root.GetText()); text != "hello" {
+		t.Fatalf("got text %q", text)
+	}
+}
@@ -1,7 +1,8 @@
 package rtfconverter
 
 import (
-	"errors"
+	"bytes"
+	"fmt"
 )
 
 var CRC32_TABLE []int
@@ -21,131 +22,74 @@ func init() {
 	}
 }
 
+const (
+	MAGIC_COMPRESSED   = 0x75465a4c // LZFu
+	MAGIC_UNCOMPRESSED = 0x414c454d // MELA
+	DICT_SIZE          = 4096
+	DICT_MASK          = DICT_SIZE - 1 // for quick modulo operations
+)
+
+// the dictionary is pre-loaded with these bytes before the compression / decompression starts
+const COMPRESSED_RTF_PREBUF = "{\\rtf1\\ansi\\mac\\deff0\\deftab720{\\fonttbl;}" +
+	"{\\f0\\fnil \\froman \\fswiss \\fmodern \\fscript " +
+	"\\fdecor MS Sans SerifSymbolArialTimes New RomanCourier" +
+	"{\\colortbl\\red0\\green0\\blue0\n\r\\par " +
+	"\\pard\\plain\\f0\\fs20\\b\\i\\u\\tab\\tx"
+
+// the maximum size of the data returned by Decompress and NewDecompressReader
+const DefaultMaxDecompressedSize = 128 * 1024 * 1024
+
+/**
+ * decompress a compressed RTF (eg: the PR_RTF_COMPRESSED property of a message)
+ * the decompressed data can not be larger than DefaultMaxDecompressedSize
+ */
 func Decompress(src []byte) ([]byte, error) {
-	const (
-		MAGIC_COMPRESSED   = 0x75465a4c
-		MAGIC_UNCOMPRESSED = 0x414c454d
-		DICT_SIZE          = 4096
-		DICT_MASK          = DICT_SIZE - 1 // for quick modulo operations
-	)
-
-	var (
-		dst []byte     // destination for uncompressed bytes
-		in  int    = 0 // current position in src array
-		out int    = 0 // current position in dst array
-	)
-
-	prebuf :=
-		"{\\rtf1\\ansi\\mac\\deff0\\deftab720{\\fonttbl;}" +
-			"{\\f0\\fnil \\froman \\fswiss \\fmodern \\fscript " +
-			"\\fdecor MS Sans SerifSymbolArialTimes New RomanCourier" +
-			"{\\colortbl\\red0\\green0\\blue0\n\r\\par " +
-			"\\pard\\plain\\f0\\fs20\\b\\i\\u\\tab\\tx"
-
-	COMPRESSED_RTF_PREBUF := []byte(prebuf)
+	return DecompressWithLimit(src, DefaultMaxDecompressedSize)
+}
 
+/**
+ * decompress a compressed RTF; the decompressed data can not be larger than maxSize bytes (0 - no limit)
+ *
+ * the sizes from header are not trusted: the output grows with the data actually decompressed, the references are
+ * resolved in the 4096 bytes dictionary and a data that ends without the end of data marker is reported as truncated
+ */
+func DecompressWithLimit(src []byte, maxSize int) ([]byte, error) {
 	// Get header fields
-	if src == nil || len(src) < 16 {
-		return nil, errors.New("Invalid compressed-RTF header")
+	if len(src) < 16 {
+		return nil, fmt.Errorf("%w: invalid header", ErrInvalidCompressedRTF)
 	}
 
-	compressedSize := int(getU32(src, in))
-	in += 4
-
-	uncompressedSize := int(getU32(src, in))
-	in += 4
-
-	magic := int(getU32(src, in))
-	in += 4
+	compressedSize := int(getU32(src, 0))
+	uncompressedSize := int(getU32(src, 4))
+	magic := int(getU32(src, 8))
 
 	// Note: CRC must be validated only for compressed data (and includes padding)
-	crc32sum := int(getU32(src, in))
-	in += 4
+	crc32sum := int(getU32(src, 12))
 
 	if compressedSize != len(src)-4 {
 		// Check size excluding the size field itself
-		return nil, errors.New("compressed data size mismatch")
+		return nil, fmt.Errorf("%w: compressed data size mismatch", ErrInvalidCompressedRTF)
 	}
 
-	// Process the data
-	if magic == MAGIC_UNCOMPRESSED {
-		return src, nil
-	} else if magic == MAGIC_COMPRESSED {
-		if crc32sum != calculateCRC32(src, 16, len(src)-16) {
-			return nil, errors.New("compressed-RTF CRC32 failed")
-		}
-
-		out = len(COMPRESSED_RTF_PREBUF)
-		dst = make([]byte, out+uncompressedSize)
-
-		for i, v := range COMPRESSED_RTF_PREBUF {
-			dst[i] = v
-		}
-
-		var flagCount int = 0
-		var flags int = 0
-
-		for {
-
-			// Each flag byte controls 8 literals/references, 1 per bit
-			// Each bit is 1 for reference, 0 for literal
-			if (flagCount & 7) == 0 {
-				flags = int(src[in])
-				in += 1
-			} else {
-				flags = flags >> 1
-			}
-			flagCount += 1
-
-			if (flags & 1) == 0 {
-				dst[out] = src[in]
-				out += 1
-				in += 1
-
-			} else {
-
-				// Read reference: 12-bit offset (from block start) and 4-bit length
-				offset := int(src[in]) & 0xFF
-				in += 1
-
-				length := int(src[in]) & 0xFF
-				in += 1
-
-				offset = (offset << 4) | shiftZeroLeft(length, 4) // the offset from block start
-				length = (length & 0xF) + 2                       // the number of bytes to copy
-
-				// The decompression buffer is supposed to wrap around back
-				// to the beginning when the end is reached. we save the
-				// need for such a buffer by pointing straight into the data
-				// buffer, and simulating this behaviour by modifying the
-				// pointers appropriately.
-				offset = out & ^DICT_MASK | offset // the absolute offset in array
-
-				if offset >= out {
-					if offset == out {
-						break // a self-reference marks the end of data
-					}
-					offset -= DICT_SIZE // take from previous block
-				}
-				// Note: can't use System.arraycopy, because the referenced
-				// bytes can cross through the current out position.
-				end := offset + length
-
-				for offset < end {
-					dst[out] = dst[offset]
-					out += 1
-					offset += 1
-				}
-			}
-		}
+	if magic == MAGIC_COMPRESSED && crc32sum != calculateCRC32(src, 16, len(src)-16) {
+		return nil, fmt.Errorf("%w: CRC32 failed", ErrInvalidCompressedRTF)
+	}
 
-		return dst[len(COMPRESSED_RTF_PREBUF):], nil
+	// the header size is only a hint: a compressed byte can not produce more than 17 bytes
+	capacity := uncompressedSize
+	if capacity > len(src)*compressMaxMatch {
+		capacity = len(src) * compressMaxMatch
+	}
+	if maxSize > 0 && capacity > maxSize {
+		capacity = maxSize
+	}
 
-	} else { // unknown magic number
-		return nil, errors.New("Unknown compression type (magic number " + string(magic) + ")")
+	dst := bytes.NewBuffer(make([]byte, 0, capacity))
+	if _, err := dst.ReadFrom(NewDecompressReaderWithLimit(bytes.NewReader(src), maxSize)); err != nil {
+		return nil, err
 	}
 
-	return dst, nil
+	return dst.Bytes(), nil
 }
 
 /**
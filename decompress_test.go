@@ -0,0 +1,140 @@
+package rtfconverter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+const fuzzMaxDecompressedSize = 1 << 20
+
+/**
+ * a compressed RTF with the given compressed data (flag bytes, literals and references) and a valid header and CRC
+ */
+func compressedRtfFromData(rawSize int, data []byte) []byte {
+	return compressedRtfHeader(MAGIC_COMPRESSED, rawSize, data)
+}
+
+/**
+ * a reference of the compressed data: 12-bit offset and 4-bit length (length - 2)
+ */
+func compressedRtfReference(offset int, length int) []byte {
+	ref := make([]byte, 2)
+	binary.BigEndian.PutUint16(ref, uint16(offset&DICT_MASK)<<4|uint16(length-compressMinMatch))
+	return ref
+}
+
+func decompressSeeds() map[string][]byte {
+	prebufLength := len(COMPRESSED_RTF_PREBUF)
+
+	// the header claims a huge raw size: the uncompressed form has not enough data
+	oversizedRaw, _ := CompressUncompressed([]byte("{\\rtf1 abc}"))
+	binary.LittleEndian.PutUint32(oversizedRaw[4:], 0xFFFFFFFF)
+
+	// the header claims a huge raw size: the compressed form is decoded with the data actually found
+	oversizedCompressed, _ := Compress([]byte("{\\rtf1 abc}"))
+	binary.LittleEndian.PutUint32(oversizedCompressed[4:], 0xFFFFFFFF)
+
+	// a reference of 17 bytes while the header claims 2 bytes, followed by the end of data
+	overrun := []byte{0x03}
+	overrun = append(overrun, compressedRtfReference(0, compressMaxMatch)...)
+	overrun = append(overrun, compressedRtfReference(prebufLength+compressMaxMatch, compressMinMatch)...)
+
+	// literals only, without the self-reference
+	noTerminator := []byte{0x00, 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h'}
+
+	badMagic := make([]byte, 16)
+	binary.LittleEndian.PutUint32(badMagic[0:], 12)
+	binary.LittleEndian.PutUint32(badMagic[8:], 0xDEADBEEF)
+
+	valid, _ := Compress([]byte("{\\rtf1\\ansi\\pard hello hello hello\\par}"))
+
+	return map[string][]byte{
+		"truncated header":     {0x10, 0x00, 0x00, 0x00, 0x05},
+		"bad magic":            badMagic,
+		"oversized raw size":   oversizedRaw,
+		"oversized compressed": oversizedCompressed,
+		"reference overrun":    compressedRtfFromData(2, overrun),
+		"no terminator":        compressedRtfFromData(8, noTerminator),
+		"valid":                valid,
+	}
+}
+
+func TestDecompressErrors(t *testing.T) {
+	seeds := decompressSeeds()
+
+	tests := []struct {
+		name    string
+		maxSize int
+		err     error
+	}{
+		{"truncated header", 0, ErrInvalidCompressedRTF},
+		{"bad magic", 0, ErrInvalidCompressedRTF},
+		{"oversized raw size", 0, ErrInvalidCompressedRTF},
+		{"reference overrun", 8, ErrDecompressedSizeExceeded},
+		{"no terminator", 0, ErrTruncatedCompressedRTF},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := DecompressWithLimit(seeds[test.name], test.maxSize)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected %v, got %v", test.err, err)
+			}
+		})
+	}
+
+	// a truncated stream is also an invalid compressed RTF
+	if _, err := Decompress(seeds["no terminator"]); !errors.Is(err, ErrInvalidCompressedRTF) {
+		t.Fatalf("expected %v, got %v", ErrInvalidCompressedRTF, err)
+	}
+}
+
+func TestDecompressUntrustedSizes(t *testing.T) {
+	seeds := decompressSeeds()
+
+	out, err := Decompress(seeds["oversized compressed"])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(out) != "{\\rtf1 abc}" {
+		t.Fatalf("unexpected data: %q", out)
+	}
+
+	out, err = Decompress(seeds["reference overrun"])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(out) != COMPRESSED_RTF_PREBUF[:compressMaxMatch] {
+		t.Fatalf("unexpected data: %q", out)
+	}
+}
+
+func FuzzDecompress(f *testing.F) {
+	for _, seed := range decompressSeeds() {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, src []byte) {
+		out, err := DecompressWithLimit(src, fuzzMaxDecompressedSize)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidCompressedRTF) && !errors.Is(err, ErrDecompressedSizeExceeded) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			return
+		}
+		if len(out) > fuzzMaxDecompressedSize {
+			t.Fatalf("decompressed %d bytes, more than %d", len(out), fuzzMaxDecompressedSize)
+		}
+
+		// the streaming decompressor returns the same data
+		var streamed bytes.Buffer
+		if _, err := streamed.ReadFrom(NewDecompressReaderWithLimit(bytes.NewReader(src), fuzzMaxDecompressedSize)); err != nil {
+			t.Fatalf("streaming decompressor failed: %v", err)
+		}
+		if !bytes.Equal(out, streamed.Bytes()) {
+			t.Fatalf("streaming decompressor returned different data")
+		}
+	})
+}
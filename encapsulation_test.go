@@ -0,0 +1,122 @@
+package rtfconverter
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDetectEncapsulation(t *testing.T) {
+	tests := []struct {
+		name          string
+		rtf           string
+		encapsulation RtfEncapsulation
+	}{
+		{"native", "{\\rtf1\\ansi\\deff0 text}", RtfEncapsulationNone},
+		{"html", "{\\rtf1\\ansi\\fromhtml1 {\\*\\htmltag64 <p>}}", RtfEncapsulationHtml},
+		{"text", "{\\rtf1\\ansi\\fromtext text}", RtfEncapsulationText},
+		{"first word wins", "{\\rtf1\\fromtext\\fromhtml1 text}", RtfEncapsulationText},
+		// the texts and the control symbols are not inspected tokens
+		{"after texts", "{\\rtf1 a\\'e9 b\\~c d e f g h i j k\\fromhtml1 text}", RtfEncapsulationHtml},
+		{"tenth token", "{\\rtf1" + strings.Repeat("{\\x}", 3) + "\\fromhtml1 text}", RtfEncapsulationHtml},
+		{"after ten tokens", "{\\rtf1" + strings.Repeat("{\\x}", 4) + "\\fromhtml1 text}", RtfEncapsulationNone},
+		{"nested", "{\\rtf1{\\fonttbl{\\fromtext}}text}", RtfEncapsulationText},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewConverter()
+			if err := c.SetBytes([]byte(test.rtf)); err != nil {
+				t.Fatalf("load failed: %v", err)
+			}
+			if encapsulation := c.DetectEncapsulation(); encapsulation != test.encapsulation {
+				t.Fatalf("got %s, expected %s", encapsulation, test.encapsulation)
+			}
+
+			// the stream conversions select the same interpreter as the tree conversions
+			for _, format := range []string{"html", "text"} {
+				expected, err := c.Convert(format)
+				if err != nil {
+					t.Fatalf("conversion failed: %v", err)
+				}
+				streamed, err := c.ConvertReader(strings.NewReader(test.rtf), format)
+				if err != nil {
+					t.Fatalf("stream conversion failed: %v", err)
+				}
+				if string(streamed) != string(expected) {
+					t.Fatalf("%s: stream conversion returned %q, expected %q", format, streamed, expected)
+				}
+			}
+		})
+	}
+
+	var empty RtfStructure
+	if encapsulation := empty.DetectEncapsulation(); encapsulation != RtfEncapsulationNone {
+		t.Fatalf("empty document: got %s", encapsulation)
+	}
+}
+
+func TestStrictEncapsulation(t *testing.T) {
+	documents := map[RtfEncapsulation]string{
+		RtfEncapsulationNone: "{\\rtf1\\ansi text\\par}",
+		RtfEncapsulationHtml: "{\\rtf1\\ansi\\fromhtml1 {\\*\\htmltag64 <p>}text{\\*\\htmltag72 </p>}}",
+		RtfEncapsulationText: "{\\rtf1\\ansi\\fromtext text\\par}",
+	}
+
+	tests := []struct {
+		encapsulation RtfEncapsulation
+		format        string
+		err           bool
+	}{
+		{RtfEncapsulationNone, "html", false},
+		{RtfEncapsulationNone, "text", false},
+		{RtfEncapsulationHtml, "html", false},
+		{RtfEncapsulationHtml, "text", true},
+		{RtfEncapsulationText, "html", true},
+		{RtfEncapsulationText, "text", false},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.encapsulation)+" to "+test.format, func(t *testing.T) {
+			rtf := documents[test.encapsulation]
+
+			// the documents are converted without the strict mode
+			lenient := NewConverter()
+			if _, err := lenient.ConvertReader(strings.NewReader(rtf), test.format); err != nil {
+				t.Fatalf("conversion failed: %v", err)
+			}
+
+			c := NewConverter(WithStrictEncapsulation(true))
+			if err := c.SetBytes([]byte(rtf)); err != nil {
+				t.Fatalf("load failed: %v", err)
+			}
+
+			_, err := c.Convert(test.format)
+			_, streamErr := c.ConvertReader(strings.NewReader(rtf), test.format)
+			writeErr := c.ConvertTo(io.Discard, test.format)
+			for _, err := range []error{err, streamErr, writeErr} {
+				if !test.err && err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if test.err && !errors.Is(err, ErrUnsupportedEncapsulation) {
+					t.Fatalf("expected %v, got %v", ErrUnsupportedEncapsulation, err)
+				}
+				if test.err && !strings.Contains(err.Error(), string(test.encapsulation)) {
+					t.Fatalf("the error %q does not name the encapsulated format", err)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamEncapsulationNotRTF(t *testing.T) {
+	for _, rtf := range []string{"", "{\\fromhtml1 text}", "{\\rtf2\\fromtext text}", "text"} {
+		for _, format := range []string{"html", "text"} {
+			c := NewConverter()
+			if _, err := c.ConvertReader(strings.NewReader(rtf), format); !errors.Is(err, ErrNotRTF) {
+				t.Fatalf("%q to %s: expected %v, got %v", rtf, format, ErrNotRTF, err)
+			}
+		}
+	}
+}
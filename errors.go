@@ -0,0 +1,80 @@
+/**
+ * errors returned by the parser, the decompressor and the interpreters
+ * the errors can be checked with errors.Is / errors.As
+ */
+
+package rtfconverter
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// the document does not start with the {\rtf root group
+	ErrNotRTF = errors.New("The RTF file is not valid.")
+
+	// a group is closed without being opened, or the document ends before all groups are closed
+	ErrUnbalancedGroups = errors.New("The RTF groups are not balanced.")
+
+	// the document does not encapsulate the requested format (eg: html requested from a \fromtext document)
+	ErrUnsupportedEncapsulation = errors.New("The RTF encapsulation is not supported.")
+
+	// there is no interpreter for the requested format
+	ErrUnsupportedFormat = errors.New("Parser for conversion do not exists.")
+
+	// the compressed RTF header, size, CRC or compression type is not valid
+	ErrInvalidCompressedRTF = errors.New("Invalid compressed RTF.")
+
+	// the compressed data ends before the end of data marker (the self-reference)
+	ErrTruncatedCompressedRTF = fmt.Errorf("%w: truncated data", ErrInvalidCompressedRTF)
+
+	// the decompressed data is larger than the allowed maximum size
+	ErrDecompressedSizeExceeded = errors.New("The decompressed RTF exceeds the maximum size.")
+
+	// the document ends before the N bytes of data of a \binN control word
+	ErrTruncatedBinary = errors.New("The RTF binary data is truncated.")
+
+	// the RTF document is larger than the maximum size of the converter options
+	ErrInputSizeExceeded = errors.New("The RTF exceeds the maximum size.")
+
+	// the RTF document has more nested groups than the maximum depth of the converter options
+	ErrMaxDepthExceeded = errors.New("The RTF groups exceed the maximum depth.")
+
+	// the code page or the charset of the converter options is not supported
+	ErrUnsupportedEncoding = errors.New("The encoding is not supported.")
+
+	// the html tag of the converter options is not a valid tag name
+	ErrInvalidStyleTag = errors.New("The style tag is not valid.")
+
+	// the selector of Find / FindAll can not be parsed
+	ErrInvalidSelector = errors.New("The selector is not valid.")
+
+	// returned by a WalkFunc to skip the children of a group; it is never returned by Walk
+	SkipGroup = errors.New("skip this group")
+)
+
+/**
+ * an error found while the RTF document is tokenized, with the position where it was found
+ */
+type ParseError struct {
+	// bytes read from the beginning of the document
+	Offset int64
+
+	// line and column (from 1) of the position
+	Line   int
+	Column int
+
+	// how many groups are opened
+	Depth int
+
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s (offset %d, line %d, column %d, group depth %d)", e.Err.Error(), e.Offset, e.Line, e.Column, e.Depth)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
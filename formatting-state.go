@@ -0,0 +1,360 @@
+/**
+ * the character and paragraph formatting of the parsed text, shared by the interpreters
+ * the formatting is saved when a group starts ({) and restored when the group ends (}); \plain resets the character
+ * formatting and \pard resets the paragraph formatting (and the character formatting set by the paragraph style)
+ */
+
+package rtfconverter
+
+import (
+	"strings"
+)
+
+/**
+ * the underline words and the css text-decoration-style of the underline
+ */
+var rtfUnderlineStyles map[string]string = map[string]string{
+	"ul":         "solid",
+	"ulw":        "solid",
+	"ulth":       "solid",
+	"uldb":       "double",
+	"uld":        "dotted",
+	"ulthd":      "dotted",
+	"uldash":     "dashed",
+	"uldashd":    "dashed",
+	"uldashdd":   "dashed",
+	"ulldash":    "dashed",
+	"ulthdash":   "dashed",
+	"ulthdashd":  "dashed",
+	"ulthdashdd": "dashed",
+	"ulthldash":  "dashed",
+	"ulwave":     "wavy",
+	"ulhwave":    "wavy",
+	"ululdbwave": "wavy",
+}
+
+type rtfFormatting struct {
+	bold   bool
+	italic bool
+
+	// css text-decoration-style of the underline; empty if the text is not underlined
+	underline string
+
+	strike      bool
+	superscript bool
+	subscript   bool
+	hidden      bool
+
+	// all caps (\caps) and small caps (\scaps)
+	caps      bool
+	smallCaps bool
+
+	// index from font table; -1 if no font was selected
+	font int
+
+	// font size in half-points; 0 if no size was selected
+	fontSize int
+
+	// indexes from color table; 0 is the auto color
+	foreground int
+	background int
+
+	// the text is not in the original content of an encapsulated document (\htmlrtf)
+	htmlrtf bool
+
+	// paragraph alignment as css text-align value; empty for the default (left) alignment
+	align string
+
+	// the paragraph is in a table (\intbl)
+	inTable bool
+
+	// the list of the paragraph (\lsN); 0 if the paragraph is not in a list
+	list      int
+	listLevel int
+
+	// the paragraph style (\sN) and the character style (\csN); -1 if no character style was selected
+	paragraphStyle int
+	characterStyle int
+
+	// the character properties set by the words of the paragraph style and not changed after; they end with the paragraph
+	paragraphStyleCharacter rtfCharacterProperty
+}
+
+/**
+ * the character properties, as flags
+ */
+type rtfCharacterProperty uint
+
+const (
+	rtfCharacterBold rtfCharacterProperty = 1 << iota
+	rtfCharacterItalic
+	rtfCharacterUnderline
+	rtfCharacterStrike
+	rtfCharacterSuperSub
+	rtfCharacterHidden
+	rtfCharacterCaps
+	rtfCharacterSmallCaps
+	rtfCharacterFont
+	rtfCharacterFontSize
+	rtfCharacterForeground
+	rtfCharacterBackground
+)
+
+/**
+ * the character properties changed by a formatting word; 0 for the other words
+ */
+func rtfWordCharacterProperty(word string) rtfCharacterProperty {
+	if _, ok := rtfUnderlineStyles[word]; ok {
+		return rtfCharacterUnderline
+	}
+
+	switch word {
+	case "b":
+		return rtfCharacterBold
+	case "i":
+		return rtfCharacterItalic
+	case "ulnone":
+		return rtfCharacterUnderline
+	case "strike", "striked":
+		return rtfCharacterStrike
+	case "super", "sub", "nosupersub":
+		return rtfCharacterSuperSub
+	case "v":
+		return rtfCharacterHidden
+	case "caps":
+		return rtfCharacterCaps
+	case "scaps":
+		return rtfCharacterSmallCaps
+	case "f":
+		return rtfCharacterFont
+	case "fs":
+		return rtfCharacterFontSize
+	case "cf":
+		return rtfCharacterForeground
+	case "cb", "chcbpat", "highlight":
+		return rtfCharacterBackground
+	}
+	return 0
+}
+
+func newRtfFormatting() rtfFormatting {
+	return rtfFormatting{font: -1, characterStyle: -1}
+}
+
+/**
+ * \plain resets the character formatting, the paragraph formatting is kept
+ */
+func (s *rtfFormatting) resetCharacterFormatting() {
+	formatting := *s
+	*s = newRtfFormatting()
+	s.htmlrtf = formatting.htmlrtf
+	s.align = formatting.align
+	s.inTable = formatting.inTable
+	s.list = formatting.list
+	s.listLevel = formatting.listLevel
+	s.paragraphStyle = formatting.paragraphStyle
+}
+
+/**
+ * \pard resets the paragraph formatting, the character formatting is kept
+ */
+func (s *rtfFormatting) resetParagraphFormatting() {
+	s.align = ""
+	s.inTable = false
+	s.list = 0
+	s.listLevel = 0
+	s.paragraphStyle = 0
+}
+
+/**
+ * the character formatting of the previous paragraph style (eg: the bold of a heading) does not continue in the next
+ * paragraph; many writers do not write \plain after \pard\sN, so the character properties set by the style words
+ * are reset to the defaults; the properties set by the document words are kept
+ */
+func (s *rtfFormatting) resetParagraphStyleCharacterFormatting() {
+	properties := s.paragraphStyleCharacter
+	s.paragraphStyleCharacter = 0
+	if properties == 0 {
+		return
+	}
+
+	defaults := newRtfFormatting()
+	if properties&rtfCharacterBold != 0 {
+		s.bold = defaults.bold
+	}
+	if properties&rtfCharacterItalic != 0 {
+		s.italic = defaults.italic
+	}
+	if properties&rtfCharacterUnderline != 0 {
+		s.underline = defaults.underline
+	}
+	if properties&rtfCharacterStrike != 0 {
+		s.strike = defaults.strike
+	}
+	if properties&rtfCharacterSuperSub != 0 {
+		s.superscript = defaults.superscript
+		s.subscript = defaults.subscript
+	}
+	if properties&rtfCharacterHidden != 0 {
+		s.hidden = defaults.hidden
+	}
+	if properties&rtfCharacterCaps != 0 {
+		s.caps = defaults.caps
+	}
+	if properties&rtfCharacterSmallCaps != 0 {
+		s.smallCaps = defaults.smallCaps
+	}
+	if properties&rtfCharacterFont != 0 {
+		s.font = defaults.font
+	}
+	if properties&rtfCharacterFontSize != 0 {
+		s.fontSize = defaults.fontSize
+	}
+	if properties&rtfCharacterForeground != 0 {
+		s.foreground = defaults.foreground
+	}
+	if properties&rtfCharacterBackground != 0 {
+		s.background = defaults.background
+	}
+}
+
+/**
+ * apply a character or paragraph formatting word; return false if the word is not a formatting word
+ * the style words apply the formatting of the style and of its base styles, so the formatting words that follow
+ * the style word change the formatting of the style
+ */
+func (s *rtfFormatting) parseControlWord(item *ControlWord, stylesheet rtfStylesheet) bool {
+	word := item.GetWord()
+
+	// a property set by the document (or by a character style) is not reset with the paragraph style
+	s.paragraphStyleCharacter &^= rtfWordCharacterProperty(word)
+
+	if style, ok := rtfUnderlineStyles[word]; ok {
+		if item.GetFlagParameter() {
+			s.underline = style
+		} else {
+			s.underline = ""
+		}
+		return true
+	}
+
+	switch word {
+	// paragraph formatting
+	case "pard":
+		s.resetParagraphStyleCharacterFormatting()
+		s.resetParagraphFormatting()
+	case "s":
+		s.resetParagraphStyleCharacterFormatting()
+		s.paragraphStyle = item.GetIntParameter()
+		s.applyStyle(stylesheet, RtfStyleParagraph, s.paragraphStyle)
+	case "ls":
+		s.list = item.GetIntParameter()
+	case "ilvl":
+		s.listLevel = item.GetIntParameter()
+	case "intbl":
+		s.inTable = true
+	case "itap":
+		s.inTable = item.GetIntParameter() > 0
+	case "ql":
+		s.align = ""
+	case "qc":
+		s.align = "center"
+	case "qr":
+		s.align = "right"
+	case "qj":
+		s.align = "justify"
+
+	// character formatting
+	case "plain":
+		s.resetCharacterFormatting()
+	case "cs":
+		s.characterStyle = item.GetIntParameter()
+		s.applyStyle(stylesheet, RtfStyleCharacter, s.characterStyle)
+	case "b":
+		s.bold = item.GetFlagParameter()
+	case "i":
+		s.italic = item.GetFlagParameter()
+	case "ulnone":
+		s.underline = ""
+	case "strike", "striked":
+		s.strike = item.GetFlagParameter()
+	case "super":
+		s.superscript = item.GetFlagParameter()
+		s.subscript = false
+	case "sub":
+		s.subscript = item.GetFlagParameter()
+		s.superscript = false
+	case "nosupersub":
+		s.superscript = false
+		s.subscript = false
+	case "v":
+		s.hidden = item.GetFlagParameter()
+	case "caps":
+		s.caps = item.GetFlagParameter()
+	case "scaps":
+		s.smallCaps = item.GetFlagParameter()
+	case "f":
+		s.font = item.GetIntParameter()
+	case "fs":
+		s.fontSize = item.GetIntParameter()
+	case "cf":
+		s.foreground = item.GetIntParameter()
+	case "cb", "chcbpat", "highlight":
+		s.background = item.GetIntParameter()
+	case "htmlrtf":
+		s.htmlrtf = item.GetFlagParameter()
+	default:
+		return false
+	}
+
+	return true
+}
+
+func (s *rtfFormatting) applyStyle(stylesheet rtfStylesheet, styleType string, number int) {
+	for _, word := range stylesheet.formatting(styleType, number) {
+		if s.parseControlWord(word, stylesheet) && styleType == RtfStyleParagraph {
+			s.paragraphStyleCharacter |= rtfWordCharacterProperty(word.GetWord())
+		}
+	}
+}
+
+/**
+ * the text as it is displayed: the all caps text is written in upper case
+ */
+func (s *rtfFormatting) displayText(text string) string {
+	if s.caps {
+		return strings.ToUpper(text)
+	}
+	return text
+}
+
+/**
+ * the formatting of the current group and the saved formatting of the parent groups
+ */
+type rtfFormattingStack struct {
+	current rtfFormatting
+	saved   []rtfFormatting
+}
+
+func newRtfFormattingStack() rtfFormattingStack {
+	return rtfFormattingStack{current: newRtfFormatting()}
+}
+
+/**
+ * a group starts: the formatting of the parent group is saved
+ */
+func (s *rtfFormattingStack) push() {
+	s.saved = append(s.saved, s.current)
+}
+
+/**
+ * a group ends: the formatting changed inside the group is lost; an unbalanced group end is ignored
+ */
+func (s *rtfFormattingStack) pop() {
+	if len(s.saved) == 0 {
+		return
+	}
+
+	s.current = s.saved[len(s.saved)-1]
+	s.saved = s.saved[:len(s.saved)-1]
+}
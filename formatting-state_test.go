@@ -0,0 +1,61 @@
+package rtfconverter
+
+import (
+	"testing"
+)
+
+func TestParagraphStyleCharacterFormatting(t *testing.T) {
+	stylesheet := newRtfStylesheet([]RtfStyle{
+		{Type: RtfStyleParagraph, Number: 0, Name: "Normal", BasedOn: -1},
+		{Type: RtfStyleParagraph, Number: 1, Name: "heading 1", BasedOn: 0, Formatting: []*ControlWord{
+			NewControlWord("b", ""),
+			NewControlWord("i", ""),
+			NewControlWord("fs", "32"),
+			NewControlWord("qc", ""),
+		}},
+	})
+
+	apply := func(s *rtfFormatting, words ...*ControlWord) {
+		for _, word := range words {
+			s.parseControlWord(word, stylesheet)
+		}
+	}
+
+	// the heading formatting ends with the paragraph, without \plain
+	s := newRtfFormatting()
+	apply(&s, NewControlWord("pard", ""), NewControlWord("s", "1"))
+	if !s.bold || !s.italic || s.fontSize != 32 || s.align != "center" {
+		t.Fatalf("heading formatting not applied: %+v", s)
+	}
+	apply(&s, NewControlWord("par", ""), NewControlWord("pard", ""), NewControlWord("s", "0"))
+	if s.bold || s.italic || s.fontSize != 0 || s.align != "" || s.paragraphStyle != 0 {
+		t.Fatalf("heading formatting continued in the next paragraph: %+v", s)
+	}
+
+	// a new style replaces the character formatting of the previous style
+	apply(&s, NewControlWord("s", "1"), NewControlWord("s", "0"))
+	if s.bold || s.italic || s.fontSize != 0 {
+		t.Fatalf("heading formatting continued with the new style: %+v", s)
+	}
+
+	// the character formatting written in the document is kept by \pard, with or without a previous style
+	for _, words := range [][]*ControlWord{
+		{NewControlWord("pard", ""), NewControlWord("s", "0"), NewControlWord("b", "")},
+		{NewControlWord("pard", ""), NewControlWord("plain", ""), NewControlWord("b", "")},
+		{NewControlWord("pard", ""), NewControlWord("s", "1"), NewControlWord("b", "")},
+	} {
+		s = newRtfFormatting()
+		apply(&s, words...)
+		apply(&s, NewControlWord("par", ""), NewControlWord("pard", ""))
+		if !s.bold {
+			t.Fatalf("\\pard reset the bold written in the document: %+v", s)
+		}
+	}
+
+	// only the properties set by the style are reset
+	s = newRtfFormatting()
+	apply(&s, NewControlWord("pard", ""), NewControlWord("s", "1"), NewControlWord("fs", "20"), NewControlWord("pard", ""))
+	if s.bold || s.italic || s.fontSize != 20 {
+		t.Fatalf("unexpected formatting after the heading: %+v", s)
+	}
+}
@@ -1,29 +1,101 @@
 package rtfconverter
 
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
 type rtfHtmlInterpreter struct {
 	content []byte
+
+	// the converter options
+	options rtfOptions
 }
 
 func (p *rtfHtmlInterpreter) Parse(rtfObj RtfStructure) ([]byte, error) {
+	buffer := bytes.Buffer{}
 
-	var (
-		result []byte
-		err error
-	)
-
-	/**
-	 *	The de-encapsulating RTF reader SHOULD<14> inspect no more than the first 10 RTF tokens
-	 *	(that is, begin group marks and control words) in the input RTF document, in sequence, starting from the beginning of the RTF document.
-	 *	If one of the control words is the FROMHTML control word, the de-encapsulating RTF reader SHOULD conclude that the RTF document contains
-	 *	an encapsulated HTML document and stop further inspection. If one of the control words is the FROMTEXT control word, the de-encapsulating
-	 *	RTF reader SHOULD conclude that the RTF document was produced from a plain text document and stop further inspection.
-	 */
-	if (rtfObj.IsHtmlEncapsulated()) {
-		// the RTF was generated from a html file
-		parser := rtfHtmlEncapsulatedInterpreter{styleTag: "span"}
-		result, err =  parser.Parse(rtfObj)
+	if err := p.ParseTo(&buffer, rtfObj); err != nil {
+		return nil, err
 	}
 
-	return result, err
+	return buffer.Bytes(), nil
 }
 
+func (p *rtfHtmlInterpreter) ParseTo(w io.Writer, rtfObj RtfStructure) (error) {
+	if !rtfObj.IsValid() {
+		return ErrNotRTF
+	}
+
+	parser, err := p.selectParser(rtfObj.DetectEncapsulation())
+	if err != nil {
+		return err
+	}
+
+	output := p.options.outputWriter(w, true)
+	if err := parseTree(parser, rtfObj, output); err != nil {
+		return err
+	}
+	return output.Close()
+}
+
+/**
+ * convert the document while it is read from the reader, without building the RTF tree
+ */
+func (p *rtfHtmlInterpreter) ParseReader(reader io.Reader) ([]byte, error) {
+	buffer := bytes.Buffer{}
+
+	if err := p.ParseReaderTo(&buffer, reader); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+/**
+ * convert the document while it is read from the reader, and write the result while it is converted
+ */
+func (p *rtfHtmlInterpreter) ParseReaderTo(w io.Writer, reader io.Reader) (error) {
+	output := p.options.outputWriter(w, true)
+
+	selectParser := func(encapsulation RtfEncapsulation) (rtfStreamVisitor, error) {
+		parser, err := p.selectParser(encapsulation)
+		if native, ok := parser.(*rtfHtmlNativeInterpreter); ok {
+			native.streaming = true
+		}
+		return parser, err
+	}
+
+	if err := parseTokenStream(reader, output, p.options, selectParser); err != nil {
+		return err
+	}
+	return output.Close()
+}
+
+/**
+ *	The de-encapsulating RTF reader SHOULD<14> inspect no more than the first 10 RTF tokens
+ *	(that is, begin group marks and control words) in the input RTF document, in sequence, starting from the beginning of the RTF document.
+ *	If one of the control words is the FROMHTML control word, the de-encapsulating RTF reader SHOULD conclude that the RTF document contains
+ *	an encapsulated HTML document and stop further inspection. If one of the control words is the FROMTEXT control word, the de-encapsulating
+ *	RTF reader SHOULD conclude that the RTF document was produced from a plain text document and stop further inspection.
+ *
+ * a document that encapsulates plain text is converted as a native RTF document, except in strict mode
+ */
+func (p *rtfHtmlInterpreter) selectParser(encapsulation RtfEncapsulation) (rtfStreamVisitor, error) {
+	switch encapsulation {
+	case RtfEncapsulationHtml:
+		// the RTF was generated from a html file
+		return &rtfHtmlEncapsulatedInterpreter{options: p.options}, nil
+	case RtfEncapsulationText:
+		if p.options.strictEncapsulation {
+			return nil, fmt.Errorf("%w (the document was produced from %s)", ErrUnsupportedEncapsulation, encapsulation)
+		}
+	}
+
+	// render the RTF formatting
+	return &rtfHtmlNativeInterpreter{
+		options: p.options,
+		runs:    rtfHtmlRunWriter{styleTag: p.options.styleTag, semanticTags: p.options.semanticTags, cssClasses: p.options.cssClasses},
+	}, nil
+}
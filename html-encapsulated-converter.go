@@ -5,11 +5,13 @@
 package rtfconverter
 
 import (
-	"errors"
+	"bufio"
 	"bytes"
+	"io"
 	"strconv"
 	"fmt"
 	"encoding/binary"
+	"unicode/utf16"
 )
 
 var rtfFontsHtmlMap map[string]string = map[string]string {
@@ -25,28 +27,21 @@ var rtfFontsHtmlMap map[string]string = map[string]string {
 
 
 type rtfHtmlEncapsulatedInterpreter struct {
-	content 				bytes.Buffer
+	content 				*bufio.Writer
 	insideHtmlTagGroup 		int
 	rtfEncoding 			string
 	defaultFont 			int
 	fontTable  				map[int]*rtfFontTableItem
 	colorTable 				[]rtfColor
-	styleTag		        string
 
-	// keep the initial state when a group is parsed
-	groupsInitialStates				[]rtfState
+	// the converter options
+	options rtfOptions
 
-	// keep the previous state of the parsed group
-	groupPreviousState		rtfState
+	// the formatting of the parsed group: the \htmlrtf fragments and the current font
+	formatting rtfFormattingStack
 
-	// keep the current state of the parsed group
-	groupCurrentState		rtfState
-
-	// count how many style tag are opened
-	styleTagOpened int
-
-	bodyStarted bool
-	bodyStopped bool
+	// the high surrogate of a \uN pair, waiting for the low surrogate
+	pendingSurrogate rune
 }
 
 
@@ -57,89 +52,6 @@ type rtfFontTableItem struct {
     familyAlternativeName string
 }
 
-type rtfState struct {
-	states map[string]string
-}
-
-func NewRtfState() (rtfState) {
-	c := rtfState{
-		states: map[string]string{
-			//"f": "0", // default font
-			//"fs": "24", // default font size
-		},
-	}
-
-	return c
-}
-
-/**
- * make a copy of the structure
- * @param  {[type]} c rtfState)     copy( [description]
- * @return {[type]}   [description]
- */
-func (c rtfState) copy() (rtfState){
-	c1 := NewRtfState()
-
-	for i,v := range c1.states {
-		c1.states[i] = v
-	}
-
-	return c1
-}
-
-func (c rtfState) stateExists(word string) (bool){
-	if _, ok := c.states[word]; ok {
-		return true
-	}
-
-	return false
-}
-
-func (c rtfState) stateValue(word string) (string){
-	if v, ok := c.states[word]; ok {
-		return v
-	}
-
-	return ""
-}
-
-/**
- * check if 2 states are the same for styling
- * @param  {[type]} c rtfState)     hasSameStyle(c1 rtfState) (rtfState [description]
- * @return {[type]}   [description]
- */
-func (c rtfState) hasSameStyle(c1 rtfState) (bool) {
-	cStyleStates := 0
-	c1StyleStates := 0
-
-	for stateWord,stateValue := range c.states {
-		if stateType, _ := DetectWordState(stateWord); stateType == "style" {
-			cStyleStates++
-			if rStateValue, ok := c1.states[stateWord]; ok {
-				if rStateValue != stateValue {
-					return false
-				}
-			} else {
-				// the c1 do not contains this tag
-				return false
-			}
-		}
-	}
-
-	for rStateWord,_ := range c.states {
-		if stateType, _ := DetectWordState(rStateWord); stateType == "style" {
-			c1StyleStates++
-		}
-	}
-
-	if c1StyleStates != cStyleStates {
-		return false
-	}
-
-
-	return true
-}
-
 type rtfColor struct {
 	r int
 	g int
@@ -152,234 +64,112 @@ type rtfColor struct {
  * @return {[type]}   [description]
  */
 func (c *rtfColor) getHexCode() (string){
-	return fmt.Sprintf("#%x%x%x", c.r, c.g, c.b)
+	return fmt.Sprintf("#%02x%02x%02x", c.r, c.g, c.b)
 }
 
 
 func (p *rtfHtmlEncapsulatedInterpreter) Parse(rtfObj RtfStructure) ([]byte, error) {
+	buffer := bytes.Buffer{}
 
-	p.content = bytes.Buffer{}
-	p.insideHtmlTagGroup = 0
-
-	if (!rtfObj.IsValid()) {
-		return nil, errors.New("The RTF file is not valid.")
+	if err := parseTree(p, rtfObj, &buffer); err != nil {
+		return nil, err
 	}
 
-	p.parseElement(rtfObj.Root)
+	return buffer.Bytes(), nil
+}
+
+func (p *rtfHtmlEncapsulatedInterpreter) startDocument(w io.Writer) {
+	p.content = bufio.NewWriter(w)
+	p.insideHtmlTagGroup = 0
+	p.rtfEncoding = p.options.defaultEncoding
+	p.formatting = newRtfFormattingStack()
+}
 
-	return p.content.Bytes(), nil
+func (p *rtfHtmlEncapsulatedInterpreter) endDocument() error {
+	return p.content.Flush()
 }
 
 /**
  * detect the rtf element from structure and decide the parser
- * @param  {[type]} p *rtfHtmlEncapsulatedInterpreter) parseElement(item rtfElement [description]
+ * @param  {[type]} p *rtfHtmlEncapsulatedInterpreter) parseElement(item Element [description]
  * @return {[type]}   [description]
  */
-func (p *rtfHtmlEncapsulatedInterpreter) parseElement(item rtfElement) {
+func (p *rtfHtmlEncapsulatedInterpreter) parseElement(item Element) {
 	switch item.(type) {
-		case *rtfGroup:
-			p.parseGroup(item.(*rtfGroup));
-		case *rtfControlSymbol:
-			p.parseControlSymbol(item.(*rtfControlSymbol));
-		case *rtfControlWord:
-			p.parseControlWord(item.(*rtfControlWord));
-		case *rtfText:
-			p.parseText(item.(*rtfText));
+		case *Group:
+			p.parseGroup(item.(*Group));
+		case *ControlSymbol:
+			p.parseControlSymbol(item.(*ControlSymbol));
+		case *ControlWord:
+			p.parseControlWord(item.(*ControlWord));
+		case *Text:
+			p.parseText(item.(*Text));
 
 	}
 }
 
 /**
  * parse a rtf group
- * @param  {[type]} p *rtfHtmlEncapsulatedInterpreter) parseGroup(item *rtfGroup [description]
+ * @param  {[type]} p *rtfHtmlEncapsulatedInterpreter) parseGroup(item *Group [description]
  * @return {[type]}   [description]
  */
-func (p *rtfHtmlEncapsulatedInterpreter) parseGroup(item *rtfGroup) {
-	children := item.GetChildren()
-
-	isHtmlTagDestinationGroup := false;
-	isStartBodyTag := false
-
-	// check if we parse a destination group that is a htmltag (a group where the first 2 childs are \*\htmltag)
-	if (item.IsDestination()) {
-		if (len(children)>=2) {
-			switch children[1].(type) {
-			case *rtfControlWord:
-				if (children[1].(*rtfControlWord).GetWord() == "htmltag") {
-					isHtmlTagDestinationGroup = true
-					if !p.bodyStarted && children[1].(*rtfControlWord).GetIntParameter() == 50 {
-						isStartBodyTag = true
-					}
-					if children[1].(*rtfControlWord).GetIntParameter() == 58 {
-						p.bodyStopped = true
-					}
-				}
-			}
-		}
-	}
+func (p *rtfHtmlEncapsulatedInterpreter) parseGroup(item *Group) {
+	walkGroup(p, item)
+}
 
-	if isHtmlTagDestinationGroup {
-		p.insideHtmlTagGroup++
+func (p *rtfHtmlEncapsulatedInterpreter) groupAction(item *Group) rtfGroupAction {
+	if item.IsFontTable() || item.IsColorTable() {
+		return rtfGroupCollect
+	} else if (item.IsStylesheet() || item.IsTrackChanges() || item.IsInfo() || item.IsListtables() || item.IsFilesTable()) {
+		// ignore all these groups
+		return rtfGroupSkip
 	}
+	return rtfGroupWalk
+}
 
+func (p *rtfHtmlEncapsulatedInterpreter) parseCollectedGroup(item *Group) {
 	if item.IsFontTable() {
 		p.parseFontTableGroup(item)
 	} else if item.IsColorTable() {
 		p.parseColorTableGroup(item)
-	} else if (item.IsStylesheet() || item.IsTrackChanges() || item.IsInfo() || item.IsListtables() || item.IsFilesTable()) {
-		// ignore all these groups
-	} else {
-
-		// if the first group
-		if (len(p.groupsInitialStates) == 0) {
-			p.groupCurrentState = NewRtfState()
-		}
-
-		// save the state of the previous group
-		p.groupsInitialStates = append(p.groupsInitialStates, p.groupCurrentState.copy())
-
-
-		// when an state is open, try to close the previous one if
-		//p.closeState()
-
-		// everytime when a group start, we open a state that will be close when exit group
-		//p.openState()
-
-		for _, child := range children {
-			p.parseElement(child)
-		}
-
-		// when a group end, we closed the state opened at the beginning, and restore previous group state
-		//p.closeState()
-
-		// restore current group to previous group states
-		p.groupCurrentState = p.groupsInitialStates[len(p.groupsInitialStates)-1]
-
-		// remove the last state from intial states array, so the last element will be the initial state of the current group
-		p.groupsInitialStates = p.groupsInitialStates[:len(p.groupsInitialStates)-1]
-
-		if (isStartBodyTag) {
-			// we can add the styles
-			p.bodyStarted = true
-		}
-
-	}
-
-	if isHtmlTagDestinationGroup {
-		p.insideHtmlTagGroup--
-		if p.insideHtmlTagGroup < 0  {
-			p.insideHtmlTagGroup = 0;
-		}
 	}
 }
 
+/**
+ * check if the group is a destination group that is a htmltag (a group where the first 2 childs are \*\htmltag)
+ * return the htmltag parameter
+ */
+func (p *rtfHtmlEncapsulatedInterpreter) htmlTagGroup(item *Group) (int, bool) {
+	children := item.GetChildren()
 
-func (p *rtfHtmlEncapsulatedInterpreter) openState() {
-
-	if !p.bodyStarted || p.bodyStopped {
-		return
-	}
-
-	style := bytes.Buffer{}
-
-	style.WriteString("style=\"")
-
-	for stateWord, stateValue := range p.groupCurrentState.states {
-		switch (stateWord) {
-			case "b":
-				if (stateValue == "1") {
-					style.WriteString("text-weight: bold;");
-				}
-			case "i":
-				if (stateValue == "1") {
-					style.WriteString("text-weight: italic;");
-				}
-			case "v":
-				if (stateValue == "0") {
-					style.WriteString("display:none;");
-				}
-			case "f":
-				fontIdx, err := strconv.Atoi(stateValue)
-
-				if err == nil {
-					if fItem, ok := p.fontTable[fontIdx]; ok {
-		    			style.WriteString("font-family:")
-		    			style.WriteString(fItem.familyName)
-		    			style.WriteString(";")
-	    			}
-				}
-			case "fs":
-				if stateValue !="" && stateValue != "0" {
-					style.WriteString("font-size:")
-					style.WriteString(stateValue)
-					style.WriteString("px;")
-				}
-			case "ul":
-				if stateValue == "1" {
-					style.WriteString("text-decoration:underline;");
-				}
-			case "strike":
-				if stateValue == "1" {
-					style.WriteString("text-decoration:line-through;");
-				}
-			case "cf","chcfpat":
-				colorIdx, err := strconv.Atoi(stateValue)
-				if err == nil && len(p.colorTable) >= colorIdx+1 {
-					style.WriteString("color:");
-					style.WriteString(p.colorTable[colorIdx].getHexCode());
-					style.WriteString(";");
-				}
-			case "cb", "chcbpat", "highlight":
-				colorIdx, err := strconv.Atoi(stateValue)
-				if err == nil && len(p.colorTable) >= colorIdx+1 {
-					style.WriteString("background-color:");
-					style.WriteString(p.colorTable[colorIdx].getHexCode());
-					style.WriteString(";");
-				}
-			case "super":
-				if stateValue == "1" {
-					style.WriteString("vertical-align: super;");
-				}
-
-
+	if (item.IsDestination() && len(children)>=2) {
+		switch children[1].(type) {
+		case *ControlWord:
+			if (children[1].(*ControlWord).GetWord() == "htmltag") {
+				return children[1].(*ControlWord).GetIntParameter(), true
+			}
 		}
 	}
-
-	style.WriteString("\"")
-
-
-	/**
-	 *  save the last group state (the state configuration at the end group) to be used as current group initial state
-	 *  init the current group state and group previous state with the initial group state
-	 */
-
-	p.openTag(p.styleTag, style.String())
+	return 0, false
 }
 
-func (p *rtfHtmlEncapsulatedInterpreter) closeState() {
-	if !p.bodyStarted || p.bodyStopped {
-		return
+func (p *rtfHtmlEncapsulatedInterpreter) startGroup(item *Group) {
+	if _, isHtmlTagDestinationGroup := p.htmlTagGroup(item); isHtmlTagDestinationGroup {
+		p.insideHtmlTagGroup++
 	}
 
-	p.styleTagOpened++
-	p.closeTag(p.styleTag)
+	p.formatting.push()
 }
 
+func (p *rtfHtmlEncapsulatedInterpreter) endGroup(item *Group) {
+	// the formatting changed inside the group is lost when the group ends
+	p.formatting.pop()
 
-func (p *rtfHtmlEncapsulatedInterpreter) openTag(tag string, attr string) {
-	p.content.WriteString("<")
-	p.content.WriteString(tag)
-	p.content.WriteString(" ")
-	p.content.WriteString(attr)
-	p.content.WriteString(">")
-}
-
-func (p *rtfHtmlEncapsulatedInterpreter) closeTag(tag string) {
-	if p.styleTagOpened > 0 {
-		p.content.WriteString("</")
-		p.content.WriteString(tag)
-		p.content.WriteString(">")
-		p.styleTagOpened--
+	if _, isHtmlTagDestinationGroup := p.htmlTagGroup(item); isHtmlTagDestinationGroup {
+		p.insideHtmlTagGroup--
+		if p.insideHtmlTagGroup < 0  {
+			p.insideHtmlTagGroup = 0;
+		}
 	}
 }
 
@@ -389,28 +179,28 @@ func (p *rtfHtmlEncapsulatedInterpreter) closeTag(tag string) {
  *   <fontnum><fontfamily><fcharset>?<fprq>?<panose>?
  *   <nontaggedname>?<fontemb>?<codepage>? <fontname><fontaltname>? ';'
  *
- * @param  {[type]} p *rtfHtmlEncapsulatedInterpreter) parseFontTableGroup(item *rtfGroup [description]
+ * @param  {[type]} p *rtfHtmlEncapsulatedInterpreter) parseFontTableGroup(item *Group [description]
  * @return {[type]}   [description]
  */
-func (p *rtfHtmlEncapsulatedInterpreter) parseFontTableGroup(item *rtfGroup) {
+func (p *rtfHtmlEncapsulatedInterpreter) parseFontTableGroup(item *Group) {
 	p.fontTable = map[int]*rtfFontTableItem{}
 	for _, child := range item.children {
 		switch child.(type) {
-		case *rtfGroup:
-			if child.(*rtfGroup).IsFontInfo() {
-				p.parseFontInfoGroup(child.(*rtfGroup))
+		case *Group:
+			if child.(*Group).IsFontInfo() {
+				p.parseFontInfoGroup(child.(*Group))
 			}
 		}
 	}
 }
-func (p *rtfHtmlEncapsulatedInterpreter) parseFontInfoGroup(item *rtfGroup) {
+func (p *rtfHtmlEncapsulatedInterpreter) parseFontInfoGroup(item *Group) {
 	var (
 		fontIdx int
 	)
 
 	for _, child := range item.GetChildren() {
 		switch cobj := child.(type) {
-			case *rtfControlWord:
+			case *ControlWord:
 				switch cobj.GetWord() {
 					case "f":
 						fontIdx = cobj.GetIntParameter()
@@ -425,18 +215,18 @@ func (p *rtfHtmlEncapsulatedInterpreter) parseFontInfoGroup(item *rtfGroup) {
 							ftItem.charsetIndex = cobj.GetIntParameter()
 						}
 				}
-			case *rtfText:
+			case *Text:
 				if ftItem, ok := p.fontTable[fontIdx]; ok {
 					ftItem.familyName = string(bytes.TrimRight(cobj.GetContent(), ";"))
 				}
-			case *rtfGroup:
+			case *Group:
 				if (cobj.IsFontAlternative()) {
 					// check alternative font
 					if len(cobj.children)>=3 {
 						switch cobj.children[2].(type) {
-							case *rtfText:
+							case *Text:
 								if ftItem, ok := p.fontTable[fontIdx]; ok {
-									ftItem.familyAlternativeName = string(cobj.children[2].(*rtfText).GetContent())
+									ftItem.familyAlternativeName = string(cobj.children[2].(*Text).GetContent())
 								}
 						}
 
@@ -450,10 +240,10 @@ func (p *rtfHtmlEncapsulatedInterpreter) parseFontInfoGroup(item *rtfGroup) {
  * extract colors from colortbl tag
  *  {\colortbl;\red0\green0\blue0;}
  * Index 0 of the RTF color table  is the 'auto' color
- * @param  {[type]} p *rtfHtmlEncapsulatedInterpreter) parseColorTableGroup(item *rtfGroup [description]
+ * @param  {[type]} p *rtfHtmlEncapsulatedInterpreter) parseColorTableGroup(item *Group [description]
  * @return {[type]}   [description]
  */
-func (p *rtfHtmlEncapsulatedInterpreter) parseColorTableGroup(item *rtfGroup) {
+func (p *rtfHtmlEncapsulatedInterpreter) parseColorTableGroup(item *Group) {
 	if (!item.IsColorTable()) {
 		return
 	}
@@ -465,17 +255,17 @@ func (p *rtfHtmlEncapsulatedInterpreter) parseColorTableGroup(item *rtfGroup) {
 	}
 	for _, child := range item.GetChildren() {
 		switch child.(type) {
-			case *rtfControlWord:
-				switch (child.(*rtfControlWord).GetWord()) {
+			case *ControlWord:
+				switch (child.(*ControlWord).GetWord()) {
 					case "red":
-						color.r = child.(*rtfControlWord).GetIntParameter()
+						color.r = child.(*ControlWord).GetIntParameter()
 					case "green":
-						color.g = child.(*rtfControlWord).GetIntParameter()
+						color.g = child.(*ControlWord).GetIntParameter()
 					case "blue":
-						color.b = child.(*rtfControlWord).GetIntParameter()
+						color.b = child.(*ControlWord).GetIntParameter()
 				}
 
-			case *rtfText:
+			case *Text:
 				// an end of color if marked by a ; text
 				p.colorTable = append(p.colorTable, color)
 
@@ -489,9 +279,9 @@ func (p *rtfHtmlEncapsulatedInterpreter) parseColorTableGroup(item *rtfGroup) {
 	}
 }
 
-func (p *rtfHtmlEncapsulatedInterpreter) parseControlSymbol(item *rtfControlSymbol) {
+func (p *rtfHtmlEncapsulatedInterpreter) parseControlSymbol(item *ControlSymbol) {
 
-	if (p.groupCurrentState.stateExists("htmlrtf") && p.groupCurrentState.stateValue("htmlrtf") == "1") {
+	if (p.formatting.current.htmlrtf) {
 		/* Outside of an HTMLTAG destination groupIgnore and skip any text and RTF control words that are suppressed
 		by any HTMLRTF control word other than the \fN control word. The de-encapsulating RTF reader SHOULD track the
 		current font even when the corresponding \fN control word is inside of a fragment that is disabled with an HTMLRTF control word.
@@ -508,7 +298,7 @@ func (p *rtfHtmlEncapsulatedInterpreter) parseControlSymbol(item *rtfControlSymb
 				b := make([]byte, 2)
 				binary.LittleEndian.PutUint16(b, uint16(v))
 				b = bytes.TrimRight(b, "\x00")
-				r, _ := ConvertToUtf8(b, p.rtfEncoding);
+				r, _ := ConvertToUtf8(b, p.currentEncoding());
 				p.content.Write(r)
 			}
 
@@ -528,45 +318,11 @@ func (p *rtfHtmlEncapsulatedInterpreter) parseControlSymbol(item *rtfControlSymb
 	}
 }
 
-/**
- * update the current state of a group
- * when the current state is updated, the previousState is closed, and a new state is opened
- *
- */
-
-func (p *rtfHtmlEncapsulatedInterpreter) updateState(word string, value string) {
-	stateScenario, stateValueType  := DetectWordState(word)
-
-	var stateValue string
-
-	switch (stateValueType) {
-		case "flag":
-			if (value == "0") {
-				stateValue = "0" // off
-			} else {
-				stateValue = "1" // on
-			}
-			break;
-		case "value":
-			stateValue = value
-	}
-
-	// update the current state with the new value
-	p.groupCurrentState.states[word] = stateValue
-
-	if stateScenario == "style" {
-		// close previous state
-		//p.closeState();
-
-		// open the new state
-		//p.openState()
-	}
-}
-
-func (p *rtfHtmlEncapsulatedInterpreter) parseControlWord(item *rtfControlWord) {
+func (p *rtfHtmlEncapsulatedInterpreter) parseControlWord(item *ControlWord) {
 	switch item.GetWord() {
-		case  "htmlrtf":
-			p.updateState(item.GetWord(), item.GetParameter())
+		case  "htmlrtf", "f":
+			// the current font is tracked also inside the htmltag groups and the fragments disabled with \htmlrtf
+			p.formatting.current.parseControlWord(item, rtfStylesheet{})
 			return
 	}
 
@@ -574,9 +330,13 @@ func (p *rtfHtmlEncapsulatedInterpreter) parseControlWord(item *rtfControlWord)
 		// no control words will be added if are inside an htmltag
 		switch  item.GetWord() {
 			case "u" :
-				p.content.WriteString("&#")
-				p.content.WriteString(item.GetParameter())
-				p.content.WriteString(";")
+				p.parseUnicode(item)
+				return
+			case "par":
+				p.content.WriteString("\r\n")
+				return
+			case "tab":
+				p.content.WriteString("\t")
 				return
 			case "lquote":
 				p.content.WriteString("&lsquo;")
@@ -602,7 +362,7 @@ func (p *rtfHtmlEncapsulatedInterpreter) parseControlWord(item *rtfControlWord)
 		}
 	} else {
 
-		if (item.word != "f" && p.groupCurrentState.stateExists("htmlrtf") && p.groupCurrentState.stateValue("htmlrtf") == "1") {
+		if (p.formatting.current.htmlrtf) {
 			/* Outside of an HTMLTAG destination groupIgnore and skip any text and RTF control words that are suppressed
 			by any HTMLRTF control word other than the \fN control word. The de-encapsulating RTF reader SHOULD track the
 			current font even when the corresponding \fN control word is inside of a fragment that is disabled with an HTMLRTF control word.
@@ -610,8 +370,16 @@ func (p *rtfHtmlEncapsulatedInterpreter) parseControlWord(item *rtfControlWord)
 			return
 		}
 
+		if p.formatting.current.parseControlWord(item, rtfStylesheet{}) {
+			// a formatting word; the formatting of the original html is written by the htmltag groups
+			return
+		}
+
 		// outside html group
 		switch  item.GetWord() {
+			case "u" :
+				p.parseUnicode(item)
+				return
 			case "line" : // new line
 				//p.content.WriteString("<br>")
 				return
@@ -631,16 +399,12 @@ func (p *rtfHtmlEncapsulatedInterpreter) parseControlWord(item *rtfControlWord)
 	        		p.rtfEncoding, _ = GetEncodingFromCodepage(item.GetParameter())
 	        	}
 	        	return
-	        case "f", "fs":
-	        	// font
-	        	p.updateState(item.GetWord(), item.GetParameter())
-	        	return
 	     }
 	}
 }
 
-func (p *rtfHtmlEncapsulatedInterpreter) parseText(item *rtfText) {
-	if (p.groupCurrentState.stateExists("htmlrtf") && p.groupCurrentState.stateValue("htmlrtf") == "1") {
+func (p *rtfHtmlEncapsulatedInterpreter) parseText(item *Text) {
+	if (p.formatting.current.htmlrtf) {
 		/* Outside of an HTMLTAG destination groupIgnore and skip any text and RTF control words that are suppressed
 		by any HTMLRTF control word other than the \fN control word. The de-encapsulating RTF reader SHOULD track the
 		current font even when the corresponding \fN control word is inside of a fragment that is disabled with an HTMLRTF control word.
@@ -649,20 +413,51 @@ func (p *rtfHtmlEncapsulatedInterpreter) parseText(item *rtfText) {
 	}
 
 	// ignore any text outside an htmlTag group
-	t, _ := ConvertToUtf8(item.GetContent(), p.rtfEncoding)
+	t, _ := ConvertToUtf8(UnescapeRtfText(item.GetContent()), p.currentEncoding())
 	p.content.Write(t)
 }
 
+/**
+ * the text is encoded with the charset of the current font, or with the document code page
+ */
+func (p *rtfHtmlEncapsulatedInterpreter) currentEncoding() string {
+	if fItem, ok := p.fontTable[p.formatting.current.font]; ok && fItem.charsetIndex > 2 {
+		if encoding, err := GetEncodingFromCharset(fItem.charsetIndex); err == nil && encoding != "" {
+			return encoding
+		}
+	}
+	return p.rtfEncoding
+}
 
 /**
- * some rtfControlWord are states; this function return an scope and a value type for a control word
- * @param stateScope string - there are states used to modify style or other (like htmlrtf) => return scope
- * @param stateValueType value of a state can be with a on/off  value (flag) or a value (value)
- *
- * @TODO:
- * 	- the words added are only for decoding an rtf that was encoded from a html source
+ * \uN - the replacement chars (\ucN) are already skipped by the tokenizer
+ * the chars outside the BMP are written as an utf-16 surrogate pair (2 \uN control words)
  */
+func (p *rtfHtmlEncapsulatedInterpreter) parseUnicode(item *ControlWord) {
+	r, err := RuneFromUnicodeParameter(item.GetParameter())
+	if err != nil {
+		return
+	}
+
+	if r >= 0xd800 && r < 0xdc00 {
+		p.pendingSurrogate = r
+		return
+	}
+
+	if p.pendingSurrogate != 0 {
+		r = utf16.DecodeRune(p.pendingSurrogate, r)
+		p.pendingSurrogate = 0
+	}
+
+	p.content.WriteString(string(r))
+}
+
 
+// DetectWordState returns the scope of a state control word ("style", or "other" for \htmlrtf) and the type of its
+// value ("flag" for on/off words, "value" for words with a value); empty values for the other words.
+//
+// Deprecated: the interpreters keep the character and paragraph formatting with rtfFormattingStack and do not use
+// this function anymore; it is kept only for compatibility.
 func DetectWordState(word string) (stateScope string, stateValueType string) {
 	switch (word) {
 		case "htmlrtf": // HTMLRTF control word identifies fragments of RTF that were not in the original HTML content
@@ -672,9 +467,12 @@ func DetectWordState(word string) (stateScope string, stateValueType string) {
 			"i",	// italic
 			"v",	// hidden
 			"ul",  // underline start
-			"uldone",  // underline stop
+			"ulnone",  // underline stop
 			"strike",  // strike
-			"super": // sup
+			"super", // sup
+			"sub", // sub
+			"caps", // all caps
+			"scaps": // small caps
 			stateScope = "style"
 			stateValueType = "flag"
 		case "f", // font index from font table
@@ -684,6 +482,7 @@ func DetectWordState(word string) (stateScope string, stateValueType string) {
 			 "cf", // foreground color (the default is 0) - font color
 
 			 "cb", //Background color (the default is 0)
+			 "highlight", // highlight color
 			 "chcbpat": // N is the fill color, specified as an index into the document's color table. -  (Character Borders and Shading) - we can use it as backgorund color
 			stateScope = "style"
 			stateValueType = "value"
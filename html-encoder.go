@@ -0,0 +1,363 @@
+/*
+	encapsulates a html document in a RTF document, the reverse of rtfHtmlEncapsulatedInterpreter
+	https://docs.microsoft.com/en-us/openspecs/exchange_server_protocols/ms-oxrtfex/906fbb0f-2467-490e-8c3e-bdc31c5e9d35
+*/
+
+package rtfconverter
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+/**
+ * the parameter of the \*\htmltagN destination: the tag id (N >> 4), the close flag and the placement of the tag
+ */
+const (
+	rtfHtmlTagInBody      = 0x0
+	rtfHtmlTagInHead      = 0x1
+	rtfHtmlTagInHtml      = 0x2
+	rtfHtmlTagOutsideHtml = 0x3
+	rtfHtmlTagClose       = 0x8
+
+	// <body> and </body>
+	rtfHtmlTagBodyStart = 50
+	rtfHtmlTagBodyEnd   = 58
+)
+
+/**
+ * the tag ids of the document structure tags; the readers find the start and the end of the body by them
+ * (eg: \*\htmltag50 for <body>), so they are written with the id and the close flag
+ *
+ * the other tags are written with the placement bits only (tag id 0): the de-encapsulating readers take the tag from
+ * the content of the group, not from its id, and writing a wrong id would be worse than writing no id
+ */
+var rtfHtmlTagIds map[string]int = map[string]int{
+	"html": 1,
+	"head": 2,
+	"body": 3,
+}
+
+// the content of these elements is not visible; it is kept only in the htmltag groups
+var rtfHtmlRawTextTags map[string]bool = map[string]bool{
+	"script": true,
+	"style":  true,
+}
+
+// the RTF rendering of the html tags, written in a \htmlrtf fragment after the htmltag group
+var rtfHtmlTagRendering map[string]string = map[string]string{
+	"br":          "\\line",
+	"/p":          "\\par",
+	"/div":        "\\par",
+	"/h1":         "\\par",
+	"/h2":         "\\par",
+	"/h3":         "\\par",
+	"/h4":         "\\par",
+	"/h5":         "\\par",
+	"/h6":         "\\par",
+	"/li":         "\\par",
+	"/tr":         "\\par",
+	"/blockquote": "\\par",
+	"/pre":        "\\par",
+	"td":          "\\tab",
+	"th":          "\\tab",
+	"b":           "\\b",
+	"/b":          "\\b0",
+	"strong":      "\\b",
+	"/strong":     "\\b0",
+	"i":           "\\i",
+	"/i":          "\\i0",
+	"em":          "\\i",
+	"/em":         "\\i0",
+	"u":           "\\ul",
+	"/u":          "\\ulnone",
+	"s":           "\\strike",
+	"/s":          "\\strike0",
+	"strike":      "\\strike",
+	"/strike":     "\\strike0",
+	"sup":         "\\super",
+	"/sup":        "\\nosupersub",
+	"sub":         "\\sub",
+	"/sub":        "\\nosupersub",
+}
+
+type rtfHtmlEncoder struct {
+	content     *bufio.Writer
+	rtfEncoding string
+	codePage    string
+
+	// the placement of the current position in the html document
+	placement int
+}
+
+/**
+ * encapsulate the html document (utf-8) in a RTF document (\fromhtml1)
+ */
+func EncodeHtml(htmlContent []byte) ([]byte, error) {
+	buffer := bytes.Buffer{}
+
+	if err := EncodeHtmlTo(&buffer, htmlContent); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+/**
+ * encapsulate the html document (utf-8) in a RTF document (\fromhtml1) written to w
+ */
+func EncodeHtmlTo(w io.Writer, htmlContent []byte) error {
+	e := rtfHtmlEncoder{
+		content:     bufio.NewWriter(w),
+		rtfEncoding: "CP1252",
+		codePage:    "1252",
+	}
+
+	return e.encode(string(htmlContent))
+}
+
+func (e *rtfHtmlEncoder) encode(content string) error {
+	// a html fragment (without <html>) is considered the body content
+	e.placement = rtfHtmlTagInBody
+	if strings.Contains(strings.ToLower(content), "<html") {
+		e.placement = rtfHtmlTagOutsideHtml
+	}
+
+	e.content.WriteString("{\\rtf1\\ansi\\ansicpg")
+	e.content.WriteString(e.codePage)
+	e.content.WriteString("\\fromhtml1 \\deff0{\\fonttbl\r\n")
+	e.content.WriteString("{\\f0\\fswiss\\fcharset0 Arial;}\r\n")
+	e.content.WriteString("{\\f1\\fmodern\\fcharset0 Courier New;}}\r\n")
+	e.content.WriteString("\\uc1\\pard\\plain\\f0\\fs24\r\n")
+
+	for i := 0; i < len(content); {
+		switch {
+		case strings.HasPrefix(content[i:], "<!--"):
+			end := strings.Index(content[i+4:], "-->")
+			if end < 0 {
+				end = len(content)
+			} else {
+				end += i + 4 + 3
+			}
+			e.writeHtmlTag(e.placement, content[i:end])
+			i = end
+		case htmlTagStarts(content, i):
+			end := htmlTagEnd(content, i)
+			i = e.encodeTag(content, content[i:end], end)
+		case content[i] == '&':
+			end := htmlEntityEnd(content, i)
+			if end < 0 {
+				e.encodeText("&")
+				i++
+				continue
+			}
+			e.encodeEntity(content[i:end])
+			i = end
+		default:
+			// a < that does not start a tag is a text char
+			end := strings.IndexAny(content[i+1:], "<&")
+			if end < 0 {
+				end = len(content)
+			} else {
+				end += i + 1
+			}
+			e.encodeText(content[i:end])
+			i = end
+		}
+	}
+
+	e.content.WriteString("}")
+
+	return e.content.Flush()
+}
+
+/**
+ * write a tag; return the position after the tag (after the raw content of <script> and <style>)
+ */
+func (e *rtfHtmlEncoder) encodeTag(content string, tag string, end int) int {
+	name, closing := htmlTagName(tag)
+
+	placement := e.placement
+	switch name {
+	case "html":
+		placement = rtfHtmlTagOutsideHtml
+		if closing {
+			e.placement = rtfHtmlTagOutsideHtml
+		} else {
+			e.placement = rtfHtmlTagInHtml
+		}
+	case "head":
+		placement = rtfHtmlTagInHtml
+		if closing {
+			e.placement = rtfHtmlTagInHtml
+		} else {
+			e.placement = rtfHtmlTagInHead
+		}
+	case "body":
+		placement = rtfHtmlTagInHtml
+		if closing {
+			e.placement = rtfHtmlTagInHtml
+		} else {
+			e.placement = rtfHtmlTagInBody
+		}
+	}
+
+	parameter := placement
+	if id, ok := rtfHtmlTagIds[name]; ok {
+		parameter |= id << 4
+		if closing {
+			parameter |= rtfHtmlTagClose
+		}
+	}
+	if closing {
+		name = "/" + name
+	}
+
+	e.writeHtmlTag(parameter, tag)
+
+	if rendering, ok := rtfHtmlTagRendering[name]; ok && e.placement == rtfHtmlTagInBody {
+		e.writeRtfFragment(rendering)
+	}
+
+	if !closing && rtfHtmlRawTextTags[name] {
+		// the raw content ends at the closing tag
+		rawEnd := strings.Index(strings.ToLower(content[end:]), "</"+name)
+		if rawEnd < 0 {
+			rawEnd = len(content)
+		} else {
+			rawEnd += end
+		}
+		if rawEnd > end {
+			e.writeHtmlTag(e.placement, content[end:rawEnd])
+		}
+		return rawEnd
+	}
+
+	return end
+}
+
+/**
+ * the entity is kept in a htmltag group; the RTF rendering is the decoded char
+ */
+func (e *rtfHtmlEncoder) encodeEntity(entity string) {
+	e.writeHtmlTag(e.placement, entity)
+
+	if e.placement == rtfHtmlTagInBody {
+		e.content.WriteString("\\htmlrtf ")
+		e.content.Write(EscapeRtfText(html.UnescapeString(entity), e.rtfEncoding))
+		e.content.WriteString("\\htmlrtf0 ")
+	}
+}
+
+/**
+ * the text of the body is visible in the RTF document; the text outside the body and the whitespaces between
+ * the tags are kept in htmltag groups
+ */
+func (e *rtfHtmlEncoder) encodeText(text string) {
+	if e.placement != rtfHtmlTagInBody || (strings.TrimSpace(text) == "" && strings.ContainsAny(text, "\r\n")) {
+		e.writeHtmlTag(e.placement, text)
+		return
+	}
+
+	e.writeEscaped(text)
+}
+
+func (e *rtfHtmlEncoder) writeHtmlTag(parameter int, content string) {
+	fmt.Fprintf(e.content, "{\\*\\htmltag%d ", parameter)
+	e.writeEscaped(content)
+	e.content.WriteString("}")
+}
+
+/**
+ * RTF content that is not in the original html
+ */
+func (e *rtfHtmlEncoder) writeRtfFragment(rtf string) {
+	e.content.WriteString("\\htmlrtf ")
+	e.content.WriteString(rtf)
+	e.content.WriteString("\\htmlrtf0 ")
+}
+
+/**
+ * escape the text; the line breaks are written as \par (the RTF readers ignore the CR and LF chars)
+ */
+func (e *rtfHtmlEncoder) writeEscaped(text string) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			e.content.WriteString("\\par\r\n")
+		}
+		e.content.Write(EscapeRtfText(line, e.rtfEncoding))
+	}
+}
+
+/**
+ * a tag starts with < followed by a letter, /, ! or ?
+ */
+func htmlTagStarts(content string, i int) bool {
+	if content[i] != '<' || i+1 >= len(content) {
+		return false
+	}
+	c := content[i+1]
+	return ByteIsAsciiLetter(c) || c == '/' || c == '!' || c == '?'
+}
+
+/**
+ * the position after the > that ends the tag; the quoted attribute values may contain >
+ */
+func htmlTagEnd(content string, start int) int {
+	var quote byte
+	for i := start + 1; i < len(content); i++ {
+		switch c := content[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i + 1
+		}
+	}
+	return len(content)
+}
+
+/**
+ * the lower case name of the tag and if it is a closing tag
+ */
+func htmlTagName(tag string) (string, bool) {
+	tag = strings.TrimPrefix(tag, "<")
+	closing := strings.HasPrefix(tag, "/")
+	tag = strings.TrimPrefix(tag, "/")
+
+	end := strings.IndexAny(tag, " \t\r\n/>")
+	if end >= 0 {
+		tag = tag[:end]
+	}
+	return strings.ToLower(tag), closing
+}
+
+/**
+ * the position after the ; that ends the entity (&name; &#N; &#xH;), -1 if there is no entity
+ */
+func htmlEntityEnd(content string, start int) int {
+	for i := start + 1; i < len(content) && i-start <= 32; i++ {
+		c := content[i]
+		switch {
+		case c == ';':
+			if i == start+1 {
+				return -1
+			}
+			return i + 1
+		case c == '#' && i == start+1:
+		case !ByteIsAsciiLetter(c) && !ByteIsDigit(c):
+			return -1
+		}
+	}
+	return -1
+}
@@ -0,0 +1,48 @@
+package rtfconverter
+
+import (
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestEncodeHtmlTagIds(t *testing.T) {
+	content := "<html><head><title>t</title></head><body><p>text</p><br></body></html><!-- end -->"
+
+	result, err := EncodeHtml([]byte(content))
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	expected := []struct {
+		tag       string
+		parameter int
+	}{
+		{"<html>", 0x13},
+		{"<head>", 0x22},
+		{"<title>", rtfHtmlTagInHead},
+		{"</title>", rtfHtmlTagInHead},
+		{"</head>", 0x2A},
+		{"<body>", rtfHtmlTagBodyStart},
+		{"<p>", rtfHtmlTagInBody},
+		{"</p>", rtfHtmlTagInBody},
+		{"<br>", rtfHtmlTagInBody},
+		{"</body>", rtfHtmlTagBodyEnd},
+		{"</html>", 0x1B},
+		{"<!-- end -->", rtfHtmlTagOutsideHtml},
+	}
+
+	groups := regexp.MustCompile(`\{\\\*\\htmltag(\d+) (<[^}]*>)\}`).FindAllStringSubmatch(string(result), -1)
+	if len(groups) != len(expected) {
+		t.Fatalf("got %d tag groups, expected %d: %s", len(groups), len(expected), result)
+	}
+
+	for i, group := range groups {
+		if group[2] != expected[i].tag {
+			t.Fatalf("tag %d is %s, expected %s", i, group[2], expected[i].tag)
+		}
+		if parameter, _ := strconv.Atoi(group[1]); parameter != expected[i].parameter {
+			t.Errorf("%s written as \\htmltag%d, expected \\htmltag%d", group[2], parameter, expected[i].parameter)
+		}
+	}
+}
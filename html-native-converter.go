@@ -0,0 +1,760 @@
+/**
+ * converts a RTF document that does not encapsulate a html document (plain RTF created by Word, WordPad, etc)
+ * paragraphs are converted to <p> and the character formatting to style tags with inline css
+ */
+
+package rtfconverter
+
+import (
+	"bufio"
+	"bytes"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+/**
+ * an opened <ul> or <ol> list; the last item of the list is opened
+ */
+type rtfHtmlOpenedList struct {
+	list   int
+	level  int
+	format string
+	tag    string
+}
+
+type rtfHtmlNativeInterpreter struct {
+	// the converted document; content is the document or the content of the current table cell
+	document    *bufio.Writer
+	content     *bufio.Writer
+	rtfEncoding string
+	defaultFont int
+	fontTable   map[int]*rtfFontTableItem
+	colorTable  []rtfColor
+
+	// the formatting of the parsed group
+	formatting rtfFormattingStack
+
+	// \'HH bytes are collected and decoded together, so the multibyte code pages are decoded correctly
+	pendingBytes []byte
+
+	// the high surrogate of a \uN pair, waiting for the low surrogate
+	pendingSurrogate rune
+
+	paragraphOpened bool
+
+	// the tag of the opened paragraph (p, h1 - h6, blockquote)
+	paragraphTag string
+
+	stylesheet rtfStylesheet
+
+	// the character formatting is written in runs
+	runs rtfHtmlRunWriter
+
+	// the converter options
+	options rtfOptions
+
+	// the error returned by the picture handler
+	err error
+
+	// the document is converted in stream mode (the tree is not built)
+	streaming bool
+
+	// in stream mode, the picture group being read
+	picture *rtfPictureBuilder
+
+	// the field of the last \fldinst, waiting for its \fldrslt
+	pendingField *RtfField
+
+	// for each opened \fldrslt group, if a link was opened for the result
+	fieldResults []bool
+	linkOpened   bool
+
+	// the table is written when it ends; the content of the current cell is written in cellContent
+	table       rtfTableBuilder
+	tableOpened bool
+	cellContent bytes.Buffer
+
+	// the list definitions and the opened lists; the opened paragraph is a list item
+	listTable      map[int]RtfList
+	numbering      rtfListNumbering
+	openedLists    []rtfHtmlOpenedList
+	listItemOpened bool
+}
+
+func (p *rtfHtmlNativeInterpreter) Parse(rtfObj RtfStructure) ([]byte, error) {
+	buffer := bytes.Buffer{}
+
+	if err := parseTree(p, rtfObj, &buffer); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (p *rtfHtmlNativeInterpreter) startDocument(w io.Writer) {
+	p.document = bufio.NewWriter(w)
+	p.content = p.document
+	p.rtfEncoding = p.options.defaultEncoding
+	p.formatting = newRtfFormattingStack()
+
+	if p.options.fullHtmlDocument {
+		p.document.WriteString("<!DOCTYPE html>\r\n<html>\r\n<head>\r\n<meta charset=\"")
+		p.document.WriteString(p.options.charset())
+		p.document.WriteString("\">\r\n")
+		if p.options.cssClasses {
+			p.document.WriteString("<style>\r\n")
+			p.document.WriteString(RtfHtmlCss)
+			p.document.WriteString("</style>\r\n")
+		}
+		p.document.WriteString("</head>\r\n<body>\r\n")
+	}
+}
+
+func (p *rtfHtmlNativeInterpreter) endDocument() error {
+	p.flushText()
+	p.closeParagraph()
+	p.closeLists()
+	p.endTable()
+
+	if p.options.fullHtmlDocument {
+		p.document.WriteString("</body>\r\n</html>\r\n")
+	}
+
+	if err := p.content.Flush(); err != nil {
+		return err
+	}
+	return p.err
+}
+
+/**
+ * detect the rtf element from structure and decide the parser
+ */
+func (p *rtfHtmlNativeInterpreter) parseElement(item Element) {
+	if p.picture != nil {
+		p.picture.parseElement(item)
+		return
+	}
+
+	if symbol, ok := item.(*ControlSymbol); !ok || symbol.GetSymbol() != "'" {
+		// the \'HH sequence ended
+		p.flushText()
+	}
+
+	switch item.(type) {
+	case *Group:
+		p.parseGroup(item.(*Group))
+	case *ControlSymbol:
+		p.parseControlSymbol(item.(*ControlSymbol))
+	case *ControlWord:
+		p.parseControlWord(item.(*ControlWord))
+	case *Text:
+		p.parseText(item.(*Text))
+	}
+}
+
+func (p *rtfHtmlNativeInterpreter) parseGroup(item *Group) {
+	walkGroup(p, item)
+}
+
+/**
+ * the font table, the color table, the stylesheet, the list tables, the pictures and the field instructions are collected;
+ * the destinations without document text are skipped (the pictures of \nonshppict are copies of the \*\shppict pictures)
+ *
+ * in stream mode the pictures are not collected: a picture is decoded while it is read and sent to the picture handler;
+ * without a handler the pictures are skipped (a data: uri needs the entire picture in memory)
+ */
+func (p *rtfHtmlNativeInterpreter) groupAction(item *Group) rtfGroupAction {
+	if p.picture != nil {
+		// the nested groups of the picture (\*\blipuid, \*\picprop, etc) are not part of the data
+		return rtfGroupSkip
+	}
+
+	if p.streaming && item.IsPicture() {
+		if p.options.skipPictures || p.options.pictureHandler == nil || p.err != nil {
+			return rtfGroupSkip
+		}
+		return rtfGroupWalk
+	}
+
+	if item.IsFontTable() || item.IsColorTable() || item.IsStylesheet() || item.IsPicture() || item.IsFieldInstruction() ||
+		item.IsListtables() || item.IsListOverrideTable() || item.IsParagraphNumbering() {
+		return rtfGroupCollect
+	}
+
+	if item.IsDestination() && item.CheckChildAtIndex(1, "shppict") {
+		return rtfGroupWalk
+	}
+
+	if item.IsSkippedDestination() {
+		return rtfGroupSkip
+	}
+
+	return rtfGroupWalk
+}
+
+func (p *rtfHtmlNativeInterpreter) parseCollectedGroup(item *Group) {
+	if item.IsFontTable() {
+		p.fontTable = extractFontTable(item)
+	} else if item.IsColorTable() {
+		p.colorTable = extractColorTable(item)
+	} else if item.IsStylesheet() {
+		p.stylesheet = newRtfStylesheet(extractStyles(item, p.rtfEncoding))
+	} else if item.IsPicture() {
+		p.writePicture(extractPicture(item))
+	} else if item.IsFieldInstruction() {
+		field := ParseFieldInstruction(decodeGroupText(item, p.rtfEncoding))
+		p.pendingField = &field
+	} else if item.IsListtables() {
+		p.listTable = extractListTable(item)
+	} else if item.IsListOverrideTable() {
+		p.numbering.lists = extractListOverrideTable(item, p.listTable)
+	} else if item.IsParagraphNumbering() && p.formatting.current.list <= 0 {
+		// the numbering of the old readers; \lsN is used if the paragraph has both
+		levelIdx, level := extractParagraphNumbering(item)
+		p.numbering.setLevel(rtfParagraphNumberingList, levelIdx, level)
+		p.formatting.current.list = rtfParagraphNumberingList
+		p.formatting.current.listLevel = levelIdx
+	}
+}
+
+/**
+ * write the picture as <img>; the size is the display size of the picture
+ */
+func (p *rtfHtmlNativeInterpreter) writePicture(picture RtfPicture) {
+	if p.err != nil || p.options.skipPictures || len(picture.Data) == 0 {
+		return
+	}
+
+	src := ""
+	if p.options.pictureHandler != nil {
+		src, p.err = p.options.pictureHandler(picture)
+		if p.err != nil {
+			return
+		}
+	} else {
+		src = picture.DataURI()
+	}
+
+	if src == "" {
+		return
+	}
+
+	img := bytes.Buffer{}
+	img.WriteString("<img src=\"")
+	img.WriteString(html.EscapeString(src))
+	img.WriteString("\"")
+	if width, height := picture.DisplaySize(); width > 0 && height > 0 {
+		img.WriteString(" width=\"")
+		img.WriteString(strconv.Itoa(width))
+		img.WriteString("\" height=\"")
+		img.WriteString(strconv.Itoa(height))
+		img.WriteString("\"")
+	}
+	img.WriteString(">")
+
+	p.writeMarkup(img.String())
+}
+
+/**
+ * the result of a HYPERLINK field is written as a link; the result of the other fields is written as it is
+ */
+func (p *rtfHtmlNativeInterpreter) startFieldResult() {
+	field := p.pendingField
+	p.pendingField = nil
+
+	link := false
+	if field != nil && !p.linkOpened && !p.formatting.current.hidden {
+		if url := field.URL(); url != "" && isSafeLinkURL(url) {
+			p.prepareTable()
+			p.closeStyleTag()
+			p.openParagraph()
+			p.content.WriteString("<a href=\"")
+			p.content.WriteString(html.EscapeString(url))
+			p.content.WriteString("\">")
+			p.linkOpened = true
+			link = true
+		}
+	}
+
+	p.fieldResults = append(p.fieldResults, link)
+}
+
+func (p *rtfHtmlNativeInterpreter) endFieldResult() {
+	if len(p.fieldResults) == 0 {
+		return
+	}
+
+	link := p.fieldResults[len(p.fieldResults)-1]
+	p.fieldResults = p.fieldResults[:len(p.fieldResults)-1]
+
+	if link {
+		p.closeLink()
+	}
+}
+
+func (p *rtfHtmlNativeInterpreter) closeLink() {
+	if p.linkOpened {
+		p.closeStyleTag()
+		p.content.WriteString("</a>")
+		p.linkOpened = false
+	}
+}
+
+/**
+ * the formatting changed inside the group is lost when the group ends
+ */
+func (p *rtfHtmlNativeInterpreter) startGroup(item *Group) {
+	p.flushText()
+	p.formatting.push()
+
+	if item.IsPicture() {
+		// only in stream mode: the picture is read element by element
+		p.picture = newRtfPictureBuilder()
+		return
+	}
+
+	if item.IsFieldResult() {
+		p.startFieldResult()
+	}
+}
+
+func (p *rtfHtmlNativeInterpreter) endGroup(item *Group) {
+	if p.picture != nil {
+		picture := p.picture.result()
+		p.picture = nil
+		p.writePicture(picture)
+		p.formatting.pop()
+		return
+	}
+
+	p.flushText()
+
+	if item.IsFieldResult() {
+		p.endFieldResult()
+	}
+
+	p.formatting.pop()
+}
+
+func (p *rtfHtmlNativeInterpreter) parseControlSymbol(item *ControlSymbol) {
+	switch item.GetSymbol() {
+	case "'":
+		// convert the string, reprezenting an hex number to a byte
+		v, err := strconv.ParseUint(item.GetParameter(), 16, 8)
+		if err == nil {
+			p.pendingBytes = append(p.pendingBytes, byte(v))
+		}
+	case "~":
+		// non breaking space
+		p.writeText("\u00a0")
+	case "_":
+		// non breaking hyphen
+		p.writeText("\u2011")
+	case "-":
+		// optional hyphen
+		p.writeText("\u00ad")
+	case "{", "}", "\\":
+		p.writeText(item.GetSymbol())
+	}
+}
+
+func (p *rtfHtmlNativeInterpreter) parseControlWord(item *ControlWord) {
+	if p.table.parseControlWord(item) || p.formatting.current.parseControlWord(item, p.stylesheet) {
+		// a table definition word or a formatting word
+		return
+	}
+
+	switch item.GetWord() {
+	case "ansi", "mac", "pc", "pca":
+		p.rtfEncoding, _ = GetEncodingFromCodepage(item.GetWord())
+	case "ansicpg":
+		if item.GetIntParameter() > 0 {
+			if encoding, err := GetEncodingFromCodepage(item.GetParameter()); err == nil {
+				p.rtfEncoding = encoding
+			}
+		}
+	case "deff":
+		p.defaultFont = item.GetIntParameter()
+
+	// special characters
+	case "par", "sect", "page":
+		p.endParagraph()
+	case "line":
+		p.writeMarkup("<br>")
+	case "tab":
+		p.writeMarkup("&nbsp;&nbsp;&nbsp;&nbsp;")
+	case "cell":
+		p.endTableCell()
+	case "row":
+		p.endTableRow()
+	case "nestcell":
+		// the nested tables are written as text
+		p.writeText(" ")
+	case "nestrow":
+		p.endParagraph()
+	case "u":
+		p.parseUnicode(item)
+	case "lquote":
+		p.writeText("\u2018")
+	case "rquote":
+		p.writeText("\u2019")
+	case "ldblquote":
+		p.writeText("\u201c")
+	case "rdblquote":
+		p.writeText("\u201d")
+	case "bullet":
+		p.writeText("\u2022")
+	case "endash":
+		p.writeText("\u2013")
+	case "emdash":
+		p.writeText("\u2014")
+	case "enspace":
+		p.writeText("\u2002")
+	case "emspace":
+		p.writeText("\u2003")
+	case "qmspace":
+		p.writeText("\u2005")
+	case "zwj":
+		p.writeText("\u200d")
+	case "zwnj":
+		p.writeText("\u200c")
+	case "ltrmark":
+		p.writeText("\u200e")
+	case "rtlmark":
+		p.writeText("\u200f")
+	}
+}
+
+/**
+ * \uN - the replacement chars are already skipped by the tokenizer
+ * the chars outside the BMP are written as an utf-16 surrogate pair (2 \uN control words)
+ */
+func (p *rtfHtmlNativeInterpreter) parseUnicode(item *ControlWord) {
+	r, err := RuneFromUnicodeParameter(item.GetParameter())
+	if err != nil {
+		return
+	}
+
+	if r >= 0xd800 && r < 0xdc00 {
+		p.pendingSurrogate = r
+		return
+	}
+
+	if p.pendingSurrogate != 0 {
+		r = utf16.DecodeRune(p.pendingSurrogate, r)
+		p.pendingSurrogate = 0
+	}
+
+	p.writeText(string(r))
+}
+
+func (p *rtfHtmlNativeInterpreter) parseText(item *Text) {
+	t, _ := ConvertToUtf8(UnescapeRtfText(item.GetContent()), p.currentEncoding())
+	p.writeText(string(t))
+}
+
+/**
+ * the text is encoded with the charset of the current font, or with the document code page
+ */
+func (p *rtfHtmlNativeInterpreter) currentEncoding() string {
+	if fItem, ok := p.fontTable[p.formatting.current.font]; ok && fItem.charsetIndex > 2 {
+		if encoding, err := GetEncodingFromCharset(fItem.charsetIndex); err == nil && encoding != "" {
+			return encoding
+		}
+	}
+	return p.rtfEncoding
+}
+
+/**
+ * decode the collected \'HH bytes
+ */
+func (p *rtfHtmlNativeInterpreter) flushText() {
+	if len(p.pendingBytes) == 0 {
+		return
+	}
+
+	t, _ := ConvertToUtf8(p.pendingBytes, p.currentEncoding())
+	p.pendingBytes = nil
+	p.writeText(string(t))
+}
+
+func (p *rtfHtmlNativeInterpreter) writeText(text string) {
+	if text == "" || !p.prepareOutput() {
+		return
+	}
+	p.content.WriteString(html.EscapeString(text))
+}
+
+func (p *rtfHtmlNativeInterpreter) writeMarkup(markup string) {
+	if !p.prepareOutput() {
+		return
+	}
+	p.content.WriteString(markup)
+}
+
+/**
+ * open the paragraph and the formatting run of the current state; return false if the content is hidden
+ */
+func (p *rtfHtmlNativeInterpreter) prepareOutput() bool {
+	if p.formatting.current.hidden {
+		return false
+	}
+
+	p.prepareTable()
+	p.openParagraph()
+
+	class := p.stylesheet.htmlCharacterClass(p.formatting.current.characterStyle)
+	p.runs.open(p.content, p.runs.newRun(p.formatting.current, class, p.fontTable, p.colorTable))
+
+	return true
+}
+
+func (p *rtfHtmlNativeInterpreter) openParagraph() {
+	if p.paragraphOpened {
+		return
+	}
+
+	if p.formatting.current.list != 0 {
+		p.openListItem()
+		return
+	}
+
+	p.closeLists()
+	p.numbering.interrupt()
+
+	tag, class := p.stylesheet.htmlParagraph(p.formatting.current.paragraphStyle)
+	p.paragraphTag = tag
+
+	p.content.WriteString("<")
+	p.content.WriteString(tag)
+	p.writeParagraphAttributes(class)
+	p.content.WriteString(">")
+	p.paragraphOpened = true
+}
+
+/**
+ * the class and the alignment of a paragraph or of a list item; the alignment is a rtf-* class with the css classes option
+ */
+func (p *rtfHtmlNativeInterpreter) writeParagraphAttributes(class string) {
+	align := p.formatting.current.align
+	if p.options.cssClasses && align != "" {
+		class = strings.TrimSpace(class + " rtf-" + align)
+		align = ""
+	}
+
+	if class != "" {
+		p.content.WriteString(" class=\"")
+		p.content.WriteString(class)
+		p.content.WriteString("\"")
+	}
+	if align != "" {
+		p.content.WriteString(" style=\"text-align:")
+		p.content.WriteString(align)
+		p.content.WriteString(";\"")
+	}
+}
+
+/**
+ * font-family css value: the font name followed by the generic family of the font
+ */
+func fontFamilyCss(fItem *rtfFontTableItem) string {
+	var families []string
+
+	if name := strings.TrimSpace(fItem.familyName); name != "" {
+		if strings.ContainsAny(name, " ,") {
+			name = "'" + strings.Replace(name, "'", "", -1) + "'"
+		}
+		families = append(families, name)
+	}
+	if generic := rtfFontsHtmlMap[fItem.familyCode]; generic != "" {
+		families = append(families, generic)
+	}
+
+	return strings.Join(families, ",")
+}
+
+func (p *rtfHtmlNativeInterpreter) closeStyleTag() {
+	p.runs.close(p.content)
+}
+
+func (p *rtfHtmlNativeInterpreter) closeParagraph() {
+	p.closeStyleTag()
+
+	// a link can't continue in the next paragraph
+	p.closeLink()
+
+	if p.paragraphOpened {
+		if !p.listItemOpened {
+			p.content.WriteString("</")
+			p.content.WriteString(p.paragraphTag)
+			p.content.WriteString(">\r\n")
+		}
+		p.paragraphOpened = false
+		p.listItemOpened = false
+	}
+}
+
+/**
+ * a paragraph in a list is written as a list item; the item stays opened, so the deeper lists are nested in it
+ */
+func (p *rtfHtmlNativeInterpreter) openListItem() {
+	list, levelIdx := p.formatting.current.list, p.formatting.current.listLevel
+	if levelIdx < 0 {
+		levelIdx = 0
+	}
+
+	level := p.numbering.level(list, levelIdx)
+
+	// close the deeper lists and the other lists; a \pn paragraph may change the format of its level
+	for len(p.openedLists) > 0 {
+		last := p.openedLists[len(p.openedLists)-1]
+		if last.list == list && (last.level < levelIdx || last.level == levelIdx && last.format == level.Format) {
+			break
+		}
+		p.closeList()
+	}
+
+	number := p.numbering.nextNumber(list, levelIdx)
+
+	if len(p.openedLists) > 0 && p.openedLists[len(p.openedLists)-1].level == levelIdx {
+		p.content.WriteString("</li>\r\n")
+	} else {
+		p.openList(list, levelIdx, level, number)
+	}
+
+	p.content.WriteString("<li")
+	p.writeParagraphAttributes("")
+	p.content.WriteString(">")
+
+	p.paragraphOpened = true
+	p.listItemOpened = true
+}
+
+var rtfHtmlListTypes map[string]string = map[string]string{
+	"upper-roman": "I",
+	"lower-roman": "i",
+	"upper-alpha": "A",
+	"lower-alpha": "a",
+}
+
+func (p *rtfHtmlNativeInterpreter) openList(list int, levelIdx int, level RtfListLevel, number int) {
+	tag := "ol"
+	if level.Format == "bullet" || level.Format == "none" {
+		tag = "ul"
+	}
+
+	p.content.WriteString("<")
+	p.content.WriteString(tag)
+	if listType, ok := rtfHtmlListTypes[level.Format]; ok {
+		p.content.WriteString(" type=\"")
+		p.content.WriteString(listType)
+		p.content.WriteString("\"")
+	}
+	if tag == "ol" && number != 1 {
+		p.content.WriteString(" start=\"")
+		p.content.WriteString(strconv.Itoa(number))
+		p.content.WriteString("\"")
+	}
+	if level.Format == "none" {
+		p.content.WriteString(" style=\"list-style-type:none;\"")
+	}
+	p.content.WriteString(">\r\n")
+
+	p.openedLists = append(p.openedLists, rtfHtmlOpenedList{list: list, level: levelIdx, format: level.Format, tag: tag})
+}
+
+func (p *rtfHtmlNativeInterpreter) closeList() {
+	last := p.openedLists[len(p.openedLists)-1]
+	p.openedLists = p.openedLists[:len(p.openedLists)-1]
+
+	p.content.WriteString("</li>\r\n</")
+	p.content.WriteString(last.tag)
+	p.content.WriteString(">\r\n")
+}
+
+func (p *rtfHtmlNativeInterpreter) closeLists() {
+	for len(p.openedLists) > 0 {
+		p.closeList()
+	}
+}
+
+/**
+ * \par ends the paragraph; an empty paragraph is kept as an empty line
+ */
+func (p *rtfHtmlNativeInterpreter) endParagraph() {
+	if !p.paragraphOpened {
+		if p.formatting.current.hidden {
+			return
+		}
+		p.writeMarkup("<br>")
+	}
+	p.closeParagraph()
+}
+
+/**
+ * a paragraph in a table is written in the current cell; the table ends (and it is written) when a paragraph
+ * that is not in the table is written
+ */
+func (p *rtfHtmlNativeInterpreter) prepareTable() {
+	if p.formatting.current.inTable {
+		if !p.tableOpened {
+			p.closeParagraph()
+			p.closeLists()
+			p.content = bufio.NewWriter(&p.cellContent)
+			p.tableOpened = true
+		}
+	} else if p.tableOpened {
+		p.endTable()
+	}
+}
+
+/**
+ * \cell ends the content of the cell; a \cell without \intbl is in a table too
+ */
+func (p *rtfHtmlNativeInterpreter) endTableCell() {
+	p.formatting.current.inTable = true
+	p.prepareTable()
+	p.closeParagraph()
+	p.closeLists()
+
+	p.content.Flush()
+	p.table.endCell(p.cellContent.String())
+	p.cellContent.Reset()
+}
+
+func (p *rtfHtmlNativeInterpreter) endTableRow() {
+	p.formatting.current.inTable = true
+	p.prepareTable()
+	p.closeParagraph()
+	p.closeLists()
+
+	// the content after the last cell of the row is not in a cell
+	p.content.Flush()
+	p.cellContent.Reset()
+	p.table.endRow()
+}
+
+func (p *rtfHtmlNativeInterpreter) endTable() {
+	if !p.tableOpened {
+		return
+	}
+
+	p.closeParagraph()
+	p.closeLists()
+	p.content.Flush()
+	if strings.TrimSpace(p.cellContent.String()) != "" {
+		// the last cell was not ended with \cell
+		p.table.endCell(p.cellContent.String())
+	}
+	p.cellContent.Reset()
+
+	p.content = p.document
+	p.tableOpened = false
+
+	if !p.table.isEmpty() {
+		p.content.WriteString(htmlTable(p.table.table(), p.colorTable))
+	}
+}
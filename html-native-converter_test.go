@@ -0,0 +1,110 @@
+package rtfconverter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHtmlNativeConverter(t *testing.T) {
+	tests := []struct {
+		name string
+		rtf  string
+		html string
+	}{
+		{
+			"paragraphs",
+			"{\\rtf1\\ansi\\deff0{\\fonttbl{\\f0 Arial;}}\\pard first\\par\\pard\\qc second\\line third\\par}",
+			"<p>first</p>\r\n<p style=\"text-align:center;\">second<br>third</p>\r\n",
+		},
+		{
+			"escaping",
+			"{\\rtf1\\ansi\\ansicpg1252 <a> & \"b\" \\{c\\} caf\\'e9 \\u8364?\\par}",
+			"<p>&lt;a&gt; &amp; &#34;b&#34; {c} café €</p>\r\n",
+		},
+		{
+			"character formatting",
+			"{\\rtf1{\\colortbl;\\red255\\green0\\blue0;}\\pard\\b bold\\b0  \\i\\cf1 red\\par}",
+			"<p><span style=\"font-weight:bold;\">bold</span> <span style=\"font-style:italic;color:#ff0000;\">red</span></p>\r\n",
+		},
+		{
+			"links",
+			"{\\rtf1 {\\field{\\*\\fldinst HYPERLINK \"http://x.test/?a=1&b=2\"}{\\fldrslt ok}} " +
+				"{\\field{\\*\\fldinst HYPERLINK \"java\\tab script:alert(1)\"}{\\fldrslt tab}} " +
+				"{\\field{\\*\\fldinst HYPERLINK \"javascript:alert(1)\"}{\\fldrslt script}} " +
+				"{\\field{\\*\\fldinst HYPERLINK \"data:text/html,x\"}{\\fldrslt data}}\\par}",
+			"<p><a href=\"http://x.test/?a=1&amp;b=2\">ok</a> tab script data</p>\r\n",
+		},
+		{
+			"images",
+			"{\\rtf1 a{\\pict\\pngblip\\picwgoal300\\pichgoal150 89504e47}b{\\*\\nonshppict{\\pict\\wmetafile8 0100}}\\par}",
+			"<p>a<img src=\"data:image/png;base64,iVBORw==\" width=\"20\" height=\"10\">b</p>\r\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := convertRtf(t, []byte(test.rtf), "html"); got != test.html {
+				t.Fatalf("got %q, expected %q", got, test.html)
+			}
+		})
+	}
+}
+
+func TestHtmlNativeFullDocument(t *testing.T) {
+	html := convertRtf(t, []byte("{\\rtf1 text\\par}"), "html", WithFullHtmlDocument(true))
+
+	expected := "<!DOCTYPE html>\r\n<html>\r\n<head>\r\n<meta charset=\"utf-8\">\r\n</head>\r\n<body>\r\n" +
+		"<p>text</p>\r\n</body>\r\n</html>\r\n"
+	if html != expected {
+		t.Fatalf("got %q, expected %q", html, expected)
+	}
+}
+
+func TestHtmlNativeStreamPictures(t *testing.T) {
+	rtf := "{\\rtf1\\ansi a{\\*\\shppict{\\pict{\\*\\picprop{\\sp{\\sn x}{\\sv 1}}}\\pngblip\\picw2\\pich3\r\n" +
+		"89504e\r\n470d0a 1a0a}}{\\pict\\jpegblip\\bin3 \xff\xd8\xff}b}"
+
+	var pictures []RtfPicture
+	handler := func(picture RtfPicture) (string, error) {
+		pictures = append(pictures, picture)
+		return "cid:" + picture.Format, nil
+	}
+
+	c := NewConverter(WithPictureHandler(handler))
+	result, err := c.ConvertReader(strings.NewReader(rtf), "html")
+	if err != nil {
+		t.Fatalf("conversion failed: %v", err)
+	}
+
+	if len(pictures) != 2 {
+		t.Fatalf("got %d pictures, expected 2", len(pictures))
+	}
+	if pictures[0].Format != "png" || pictures[0].Width != 2 || pictures[0].Height != 3 ||
+		!bytes.Equal(pictures[0].Data, []byte{0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a}) {
+		t.Fatalf("unexpected png picture: %+v", pictures[0])
+	}
+	if pictures[1].Format != "jpeg" || !bytes.Equal(pictures[1].Data, []byte{0xff, 0xd8, 0xff}) {
+		t.Fatalf("unexpected jpeg picture: %+v", pictures[1])
+	}
+
+	html := string(result)
+	if !strings.Contains(html, "a<img src=\"cid:png\" width=\"2\" height=\"3\"><img src=\"cid:jpeg\">b") {
+		t.Fatalf("unexpected html: %s", html)
+	}
+
+	// the stream conversion without handler does not keep the pictures in memory to write data: uris
+	c = NewConverter()
+	result, err = c.ConvertReader(strings.NewReader(rtf), "html")
+	if err != nil {
+		t.Fatalf("conversion failed: %v", err)
+	}
+	if strings.Contains(string(result), "<img") || !strings.Contains(string(result), "ab") {
+		t.Fatalf("unexpected html: %s", result)
+	}
+
+	// the tree conversion writes the data: uris
+	if html := convertRtf(t, []byte(rtf), "html"); !strings.Contains(html, "<img src=\"data:image/png;base64,iVBORw0KGgo=\"") {
+		t.Fatalf("unexpected html: %s", html)
+	}
+}
@@ -0,0 +1,239 @@
+/**
+ * write the formatting runs of the html conversion: the adjacent text with the same formatting is written in one run,
+ * and the tags of a run are closed before the next run or any other markup is written, so the markup is always balanced
+ */
+
+package rtfconverter
+
+import (
+	"bufio"
+	"bytes"
+	"html"
+	"strconv"
+	"strings"
+)
+
+/**
+ * the tags and the attributes of a formatting run
+ */
+type rtfHtmlRun struct {
+	// the semantic tags of the run (b, i, u, s, sup, sub), in the opening order
+	tags []string
+
+	// the class and the inline css of the style tag
+	class string
+	style string
+}
+
+func (r rtfHtmlRun) isEmpty() bool {
+	return len(r.tags) == 0 && r.class == "" && r.style == ""
+}
+
+func (r rtfHtmlRun) equal(other rtfHtmlRun) bool {
+	if r.class != other.class || r.style != other.style || len(r.tags) != len(other.tags) {
+		return false
+	}
+	for i, tag := range r.tags {
+		if other.tags[i] != tag {
+			return false
+		}
+	}
+	return true
+}
+
+type rtfHtmlRunWriter struct {
+	// the element of the runs with a class or inline css; span if it is not set
+	styleTag string
+
+	// the bold, italic, underline, strike, superscript and subscript formatting is written as <b>, <i>, <u>, <s>,
+	// <sup> and <sub> instead of inline css
+	semanticTags bool
+
+	// the formatting without semantic tags is written as rtf-* classes instead of inline css (except the fonts, the font
+	// sizes and the colors)
+	cssClasses bool
+
+	// the opened run
+	opened bool
+	run    rtfHtmlRun
+}
+
+/**
+ * the css of the rtf-* classes
+ */
+const RtfHtmlCss = `.rtf-b{font-weight:bold}
+.rtf-i{font-style:italic}
+.rtf-u{text-decoration:underline}
+.rtf-s{text-decoration:line-through}
+.rtf-u.rtf-s{text-decoration:underline line-through}
+.rtf-u-double{text-decoration-style:double}
+.rtf-u-dotted{text-decoration-style:dotted}
+.rtf-u-dashed{text-decoration-style:dashed}
+.rtf-u-wavy{text-decoration-style:wavy}
+.rtf-sup{vertical-align:super}
+.rtf-sub{vertical-align:sub}
+.rtf-caps{text-transform:uppercase}
+.rtf-scaps{font-variant:small-caps}
+.rtf-center{text-align:center}
+.rtf-right{text-align:right}
+.rtf-justify{text-align:justify}
+`
+
+/**
+ * the run of the formatting; the character style is written as class
+ */
+func (rw *rtfHtmlRunWriter) newRun(state rtfFormatting, class string, fontTable map[int]*rtfFontTableItem, colorTable []rtfColor) rtfHtmlRun {
+	var (
+		run     rtfHtmlRun
+		classes []string
+		style   bytes.Buffer
+	)
+
+	if class != "" {
+		classes = append(classes, class)
+	}
+
+	// write a formatting as semantic tag, as class or as inline css
+	format := func(tag string, class string, css string) {
+		switch {
+		case rw.semanticTags && tag != "":
+			run.tags = append(run.tags, tag)
+		case rw.cssClasses:
+			classes = append(classes, class)
+		default:
+			style.WriteString(css)
+		}
+	}
+
+	if state.bold {
+		format("b", "rtf-b", "font-weight:bold;")
+	}
+	if state.italic {
+		format("i", "rtf-i", "font-style:italic;")
+	}
+
+	// a styled underline can't be written as <u>
+	underlineTag := ""
+	if state.underline == "solid" {
+		underlineTag = "u"
+	}
+	if state.underline != "" && state.strike && !rw.semanticTags && !rw.cssClasses {
+		style.WriteString("text-decoration:underline line-through;")
+	} else {
+		if state.underline != "" {
+			format(underlineTag, "rtf-u", "text-decoration:underline;")
+		}
+		if state.strike {
+			format("s", "rtf-s", "text-decoration:line-through;")
+		}
+	}
+	if state.underline != "" && state.underline != "solid" {
+		format("", "rtf-u-"+state.underline, "text-decoration-style:"+state.underline+";")
+	}
+
+	if state.superscript {
+		format("sup", "rtf-sup", "vertical-align:super;")
+	} else if state.subscript {
+		format("sub", "rtf-sub", "vertical-align:sub;")
+	}
+	if state.caps {
+		format("", "rtf-caps", "text-transform:uppercase;")
+	} else if state.smallCaps {
+		format("", "rtf-scaps", "font-variant:small-caps;")
+	}
+
+	if fItem, ok := fontTable[state.font]; ok {
+		if family := fontFamilyCss(fItem); family != "" {
+			style.WriteString("font-family:")
+			style.WriteString(family)
+			style.WriteString(";")
+		}
+	}
+	if state.fontSize > 0 {
+		style.WriteString("font-size:")
+		style.WriteString(strconv.FormatFloat(float64(state.fontSize)/2, 'f', -1, 64))
+		style.WriteString("pt;")
+	}
+	if state.foreground > 0 && state.foreground < len(colorTable) {
+		style.WriteString("color:")
+		style.WriteString(colorTable[state.foreground].getHexCode())
+		style.WriteString(";")
+	}
+	if state.background > 0 && state.background < len(colorTable) {
+		style.WriteString("background-color:")
+		style.WriteString(colorTable[state.background].getHexCode())
+		style.WriteString(";")
+	}
+
+	run.class = strings.Join(classes, " ")
+	run.style = style.String()
+	return run
+}
+
+/**
+ * continue the opened run if it has the same formatting; otherwise the opened run is closed and the run is opened
+ */
+func (rw *rtfHtmlRunWriter) open(w *bufio.Writer, run rtfHtmlRun) {
+	if rw.opened && rw.run.equal(run) {
+		return
+	}
+
+	rw.close(w)
+	if run.isEmpty() {
+		return
+	}
+
+	if run.class != "" || run.style != "" {
+		w.WriteString("<")
+		w.WriteString(rw.tag())
+		if run.class != "" {
+			w.WriteString(" class=\"")
+			w.WriteString(run.class)
+			w.WriteString("\"")
+		}
+		if run.style != "" {
+			w.WriteString(" style=\"")
+			w.WriteString(html.EscapeString(run.style))
+			w.WriteString("\"")
+		}
+		w.WriteString(">")
+	}
+	for _, tag := range run.tags {
+		w.WriteString("<")
+		w.WriteString(tag)
+		w.WriteString(">")
+	}
+
+	rw.opened = true
+	rw.run = run
+}
+
+/**
+ * close the tags of the opened run, in the reverse order
+ */
+func (rw *rtfHtmlRunWriter) close(w *bufio.Writer) {
+	if !rw.opened {
+		return
+	}
+
+	for i := len(rw.run.tags) - 1; i >= 0; i-- {
+		w.WriteString("</")
+		w.WriteString(rw.run.tags[i])
+		w.WriteString(">")
+	}
+	if rw.run.class != "" || rw.run.style != "" {
+		w.WriteString("</")
+		w.WriteString(rw.tag())
+		w.WriteString(">")
+	}
+
+	rw.opened = false
+	rw.run = rtfHtmlRun{}
+}
+
+func (rw *rtfHtmlRunWriter) tag() string {
+	if rw.styleTag == "" {
+		return "span"
+	}
+	return rw.styleTag
+}
@@ -0,0 +1,76 @@
+package rtfconverter
+
+import (
+	"testing"
+)
+
+func TestHtmlRuns(t *testing.T) {
+	rtf := "{\\rtf1{\\colortbl;\\red255\\green0\\blue0;}\\pard{\\b a}{\\b b}\\b c \\i d\\b0 e\\i0 f" +
+		"{\\ul\\strike g}{\\uldb h}{\\super 2}\\cf1{\\b x}\\par}"
+
+	tests := []struct {
+		name string
+		opts []Option
+		html string
+	}{
+		{
+			"inline css",
+			nil,
+			"<p><span style=\"font-weight:bold;\">abc </span><span style=\"font-weight:bold;font-style:italic;\">d</span>" +
+				"<span style=\"font-style:italic;\">e</span>f<span style=\"text-decoration:underline line-through;\">g</span>" +
+				"<span style=\"text-decoration:underline;text-decoration-style:double;\">h</span><span style=\"vertical-align:super;\">2</span>" +
+				"<span style=\"font-weight:bold;color:#ff0000;\">x</span></p>\r\n",
+		},
+		{
+			"semantic tags",
+			[]Option{WithSemanticTags(true)},
+			"<p><b>abc </b><b><i>d</i></b><i>e</i>f<u><s>g</s></u>" +
+				"<span style=\"text-decoration:underline;text-decoration-style:double;\">h</span><sup>2</sup>" +
+				"<span style=\"color:#ff0000;\"><b>x</b></span></p>\r\n",
+		},
+		{
+			"css classes",
+			[]Option{WithCssClasses(true)},
+			"<p><span class=\"rtf-b\">abc </span><span class=\"rtf-b rtf-i\">d</span><span class=\"rtf-i\">e</span>f" +
+				"<span class=\"rtf-u rtf-s\">g</span><span class=\"rtf-u rtf-u-double\">h</span><span class=\"rtf-sup\">2</span>" +
+				"<span class=\"rtf-b\" style=\"color:#ff0000;\">x</span></p>\r\n",
+		},
+		{
+			"style tag",
+			[]Option{WithSemanticTags(true), WithStyleTag("font")},
+			"<p><b>abc </b><b><i>d</i></b><i>e</i>f<u><s>g</s></u>" +
+				"<font style=\"text-decoration:underline;text-decoration-style:double;\">h</font><sup>2</sup>" +
+				"<font style=\"color:#ff0000;\"><b>x</b></font></p>\r\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if html := convertRtf(t, []byte(rtf), "html", test.opts...); html != test.html {
+				t.Fatalf("got %q, expected %q", html, test.html)
+			}
+		})
+	}
+}
+
+func TestHtmlRunsBalanced(t *testing.T) {
+	// the run is closed before the link and the paragraph markup, and opened again after it
+	rtf := "{\\rtf1\\pard\\b a {\\field{\\*\\fldinst HYPERLINK \"http://x.test/\"}{\\fldrslt b\\b0 c}} d\\par e\\par}"
+
+	html := convertRtf(t, []byte(rtf), "html", WithSemanticTags(true))
+	expected := "<p><b>a </b><a href=\"http://x.test/\"><b>b</b>c</a><b> d</b></p>\r\n<p><b>e</b></p>\r\n"
+	if html != expected {
+		t.Fatalf("got %q, expected %q", html, expected)
+	}
+}
+
+func TestHtmlRunsEmpty(t *testing.T) {
+	// the hidden text, an unknown color and an unknown font do not open a run
+	rtf := "{\\rtf1{\\colortbl;\\red255\\green0\\blue0;}\\pard a{\\v\\b hidden}\\cf9\\cb-1\\f7 b{\\b}c\\par}"
+
+	for _, opts := range [][]Option{nil, {WithSemanticTags(true)}, {WithCssClasses(true)}} {
+		if html := convertRtf(t, []byte(rtf), "html", opts...); html != "<p>abc</p>\r\n" {
+			t.Fatalf("got %q", html)
+		}
+	}
+}
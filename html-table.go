@@ -0,0 +1,103 @@
+/**
+ * write the tables rebuilt by rtfTableBuilder as html tables
+ */
+
+package rtfconverter
+
+import (
+	"strconv"
+	"strings"
+)
+
+var rtfBorderSides [4]string = [4]string{"top", "left", "bottom", "right"}
+
+/**
+ * the cell contents are already converted to html
+ */
+func htmlTable(t *rtfTable, colorTable []rtfColor) string {
+	result := strings.Builder{}
+
+	result.WriteString("<table style=\"border-collapse:collapse;\">\r\n")
+	for _, row := range t.rows {
+		result.WriteString("<tr>")
+		for _, cell := range row {
+			if cell.hidden {
+				continue
+			}
+
+			result.WriteString("<td")
+			if cell.colspan > 1 {
+				result.WriteString(" colspan=\"")
+				result.WriteString(strconv.Itoa(cell.colspan))
+				result.WriteString("\"")
+			}
+			if cell.rowspan > 1 {
+				result.WriteString(" rowspan=\"")
+				result.WriteString(strconv.Itoa(cell.rowspan))
+				result.WriteString("\"")
+			}
+			if style := htmlCellStyle(cell.def, colorTable); style != "" {
+				result.WriteString(" style=\"")
+				result.WriteString(style)
+				result.WriteString("\"")
+			}
+			result.WriteString(">")
+			result.WriteString(strings.TrimSpace(cell.content))
+			result.WriteString("</td>")
+		}
+		result.WriteString("</tr>\r\n")
+	}
+	result.WriteString("</table>\r\n")
+
+	return result.String()
+}
+
+/**
+ * the inline css of the cell: borders, shading and vertical alignment
+ */
+func htmlCellStyle(def rtfTableCellDef, colorTable []rtfColor) string {
+	style := strings.Builder{}
+
+	for side, border := range def.borders {
+		if border.style == "" {
+			continue
+		}
+
+		// 15 twips per pixel; the width of a border is at least 1 pixel
+		width := border.width / 15
+		if width < 1 {
+			width = 1
+		}
+
+		style.WriteString("border-")
+		style.WriteString(rtfBorderSides[side])
+		style.WriteString(":")
+		style.WriteString(strconv.Itoa(width))
+		style.WriteString("px ")
+		style.WriteString(border.style)
+		style.WriteString(" ")
+		style.WriteString(htmlTableColor(border.color, colorTable, "#000000"))
+		style.WriteString(";")
+	}
+
+	if def.background > 0 && def.background < len(colorTable) {
+		style.WriteString("background-color:")
+		style.WriteString(colorTable[def.background].getHexCode())
+		style.WriteString(";")
+	}
+
+	if def.verticalAlign != "" {
+		style.WriteString("vertical-align:")
+		style.WriteString(def.verticalAlign)
+		style.WriteString(";")
+	}
+
+	return style.String()
+}
+
+func htmlTableColor(idx int, colorTable []rtfColor, auto string) string {
+	if idx > 0 && idx < len(colorTable) {
+		return colorTable[idx].getHexCode()
+	}
+	return auto
+}
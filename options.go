@@ -0,0 +1,265 @@
+/**
+ * the configuration of the converter, set with the options of NewConverter:
+ *
+ *	c := NewConverter(WithDefaultCodePage(1251), WithFullHtmlDocument(true), WithMaxInputSize(10 << 20))
+ *
+ * the options are passed to the interpreters of each conversion
+ */
+
+package rtfconverter
+
+import (
+	"io"
+	"strconv"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+type rtfOptions struct {
+	// the encoding of the documents without \ansicpg
+	defaultEncoding string
+
+	// the charset of the converted document; nil for utf-8
+	outputEncoding encoding.Encoding
+	outputCharset  string
+
+	// the html conversion writes a complete html document (doctype, head and body) instead of a fragment
+	fullHtmlDocument bool
+
+	// the html conversion writes the character and paragraph formatting as rtf-* classes instead of inline css
+	cssClasses bool
+
+	// the bold, italic, underline, strike, superscript and subscript formatting is written as semantic tags
+	semanticTags bool
+
+	// the element of the html formatting runs; span if it is not set
+	styleTag string
+
+	// return the src of the pictures; the pictures are written as data: uris if there is no handler
+	pictureHandler RtfPictureHandler
+
+	// the pictures are not written in the html
+	skipPictures bool
+
+	// the maximum size in bytes and the maximum group depth of the RTF document; 0 - no limit
+	maxInputSize int64
+	maxDepth     int
+
+	// return ErrUnsupportedEncapsulation instead of converting a document that encapsulates other format
+	strictEncapsulation bool
+
+	// the first option that is not valid; the conversions return it
+	err error
+}
+
+/**
+ * an option of NewConverter
+ */
+type Option func(o *rtfOptions)
+
+func newRtfOptions(opts []Option) rtfOptions {
+	o := rtfOptions{defaultEncoding: "CP1252"}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+/**
+ * the code page of the documents that do not define it with \ansicpgN (eg: 1251); the default is 1252
+ */
+func WithDefaultCodePage(codePage int) Option {
+	return func(o *rtfOptions) {
+		enc, err := GetEncodingFromCodepage(strconv.Itoa(codePage))
+		if err != nil {
+			o.setError(ErrUnsupportedEncoding)
+			return
+		}
+		o.defaultEncoding = enc
+	}
+}
+
+/**
+ * the charset of the converted document (eg: iso-8859-1, windows-1252); the default is utf-8
+ * the chars that are not in the charset are written as html character references in html, and as the replacement char
+ * of the charset in text
+ */
+func WithOutputCharset(charset string) Option {
+	return func(o *rtfOptions) {
+		enc, err := htmlindex.Get(charset)
+		if err != nil {
+			o.setError(ErrUnsupportedEncoding)
+			return
+		}
+
+		o.outputCharset, _ = htmlindex.Name(enc)
+		if enc == unicode.UTF8 {
+			o.outputEncoding = nil
+		} else {
+			o.outputEncoding = enc
+		}
+	}
+}
+
+/**
+ * the html conversion writes a complete html document instead of a fragment; the encapsulated html documents are
+ * written as they are
+ */
+func WithFullHtmlDocument(full bool) Option {
+	return func(o *rtfOptions) {
+		o.fullHtmlDocument = full
+	}
+}
+
+/**
+ * the html conversion writes the formatting as rtf-* classes (eg: rtf-b, rtf-i, rtf-center) instead of inline css;
+ * the fonts, the font sizes and the colors are written as inline css
+ * the complete html document defines the classes, the html fragment needs the RtfHtmlCss rules
+ */
+func WithCssClasses(classes bool) Option {
+	return func(o *rtfOptions) {
+		o.cssClasses = classes
+	}
+}
+
+/**
+ * the html conversion writes the bold, italic, underline, strike, superscript and subscript formatting as <b>, <i>, <u>,
+ * <s>, <sup> and <sub> tags
+ */
+func WithSemanticTags(semantic bool) Option {
+	return func(o *rtfOptions) {
+		o.semanticTags = semantic
+	}
+}
+
+/**
+ * the element of the html formatting runs (span by default); the tag must be an html tag name (letters and digits,
+ * starting with a letter), otherwise the conversions return ErrInvalidStyleTag
+ */
+func WithStyleTag(tag string) Option {
+	return func(o *rtfOptions) {
+		if !isHtmlTagName(tag) {
+			o.setError(ErrInvalidStyleTag)
+			return
+		}
+		o.styleTag = tag
+	}
+}
+
+/**
+ * an ASCII html tag name: [a-zA-Z][a-zA-Z0-9]*
+ */
+func isHtmlTagName(tag string) bool {
+	if tag == "" || !ByteIsAsciiLetter(tag[0]) {
+		return false
+	}
+
+	for i := 1; i < len(tag); i++ {
+		if !ByteIsAsciiLetter(tag[i]) && !ByteIsDigit(tag[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+/**
+ * the html conversion writes the pictures as <img> tags with the src returned by the handler (eg: an url or a cid: reference
+ * to a saved picture); without handler the pictures are written as data: uris
+ * the stream conversions (ConvertReader, ConvertReaderTo) write the pictures only with a handler
+ */
+func WithPictureHandler(handler RtfPictureHandler) Option {
+	return func(o *rtfOptions) {
+		o.pictureHandler = handler
+	}
+}
+
+/**
+ * the html conversion does not write the pictures
+ */
+func WithSkipPictures(skip bool) Option {
+	return func(o *rtfOptions) {
+		o.skipPictures = skip
+	}
+}
+
+/**
+ * the loading and the conversion of a larger document fail with ErrInputSizeExceeded
+ */
+func WithMaxInputSize(size int64) Option {
+	return func(o *rtfOptions) {
+		o.maxInputSize = size
+	}
+}
+
+/**
+ * the loading and the conversion of a document with more nested groups fail with ErrMaxDepthExceeded
+ */
+func WithMaxDepth(depth int) Option {
+	return func(o *rtfOptions) {
+		o.maxDepth = depth
+	}
+}
+
+/**
+ * by default a document that encapsulates html is converted to text (and one that encapsulates text is converted to html)
+ * like a native RTF document; in strict mode the conversion returns ErrUnsupportedEncapsulation
+ */
+func WithStrictEncapsulation(strict bool) Option {
+	return func(o *rtfOptions) {
+		o.strictEncapsulation = strict
+	}
+}
+
+func (o *rtfOptions) setError(err error) {
+	if o.err == nil {
+		o.err = err
+	}
+}
+
+/**
+ * the RTF structure that parses the documents with the limits of the options
+ */
+func (o rtfOptions) newStructure() RtfStructure {
+	return RtfStructure{maxSize: o.maxInputSize, maxDepth: o.maxDepth}
+}
+
+/**
+ * the html charset of the converted document
+ */
+func (o rtfOptions) charset() string {
+	if o.outputEncoding == nil {
+		return "utf-8"
+	}
+	return o.outputCharset
+}
+
+type rtfNopWriteCloser struct {
+	io.Writer
+}
+
+func (w rtfNopWriteCloser) Close() error {
+	return nil
+}
+
+/**
+ * the writer of the converted document in the output charset; the writer must be closed at the end of the conversion
+ * the chars that are not in the charset are written as character references in html
+ */
+func (o rtfOptions) outputWriter(w io.Writer, html bool) io.WriteCloser {
+	if o.outputEncoding == nil {
+		return rtfNopWriteCloser{w}
+	}
+
+	encoder := o.outputEncoding.NewEncoder()
+	if html {
+		encoder = encoding.HTMLEscapeUnsupported(encoder)
+	} else {
+		encoder = encoding.ReplaceUnsupported(encoder)
+	}
+	return transform.NewWriter(w, encoder)
+}
@@ -0,0 +1,33 @@
+package rtfconverter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWithStyleTag(t *testing.T) {
+	doc := []byte("{\\rtf1\\ansi{\\colortbl;\\red255\\green0\\blue0;}\\pard\\cf1 red\\par}")
+
+	for _, tag := range []string{"", "span onmouseover=x", "span>", "1span", "spa-n", "spän"} {
+		c := NewConverter(WithStyleTag(tag))
+		if err := c.SetBytes(doc); err != nil {
+			t.Fatalf("load failed: %v", err)
+		}
+		if _, err := c.Convert("html"); !errors.Is(err, ErrInvalidStyleTag) {
+			t.Errorf("WithStyleTag(%q): expected %v, got %v", tag, ErrInvalidStyleTag, err)
+		}
+	}
+
+	c := NewConverter(WithStyleTag("font"))
+	if err := c.SetBytes(doc); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	result, err := c.Convert("html")
+	if err != nil {
+		t.Fatalf("conversion failed: %v", err)
+	}
+	if !strings.Contains(string(result), "<font ") || !strings.Contains(string(result), "</font>") {
+		t.Fatalf("style tag not used: %s", result)
+	}
+}
@@ -6,9 +6,15 @@ import (
 	"strconv"
 )
 
-type rtfElement interface {
-   setParent(p *rtfGroup)
-   GetParent() (*rtfGroup)
+/**
+ * a node of the RTF tree: Group, ControlWord, ControlSymbol, Text or Binary
+ * the parent is set when the node is added to a group (AppendChild, InsertChild, ReplaceChild)
+ */
+type Element interface {
+   setParent(p *Group)
+   GetParent() (*Group)
+   NextSibling() (Element)
+   PreviousSibling() (Element)
    Dump(level int)
 }
 
@@ -16,35 +22,35 @@ type rtfElement interface {
 /**
  * RTF Groups
  */
-type rtfGroup struct {
-	children []rtfElement
-	parent *rtfGroup
+type Group struct {
+	children []Element
+	parent *Group
 }
 
 
-func (r *rtfGroup) addChild(c rtfElement) {
+func (r *Group) addChild(c Element) {
 	c.setParent(r)
 	r.children = append(r.children, c)
 }
 
-func (r *rtfGroup) GetChildren() []rtfElement{
+func (r *Group) GetChildren() []Element{
 	return r.children
 }
 
-func (r *rtfGroup) setParent(p *rtfGroup) {
+func (r *Group) setParent(p *Group) {
 	r.parent = p
 }
 
-func (r *rtfGroup) GetParent()(*rtfGroup) {
+func (r *Group) GetParent()(*Group) {
 	return r.parent
 }
 
 
-func (r *rtfGroup) IsDestination() bool {
+func (r *Group) IsDestination() bool {
 	return r.CheckChildAtIndex(0, "*")
 }
 
-func (r *rtfGroup) IsRtfGroup() bool {
+func (r *Group) IsRtfGroup() bool {
 	return r.CheckChildAtIndex(0, "rtf")
 }
 
@@ -52,42 +58,42 @@ func (r *rtfGroup) IsRtfGroup() bool {
 /**
  * check if the group define the font table (first child must be fonttbl)
  */
-func (r *rtfGroup) IsFontTable() bool {
+func (r *Group) IsFontTable() bool {
 	return r.CheckChildAtIndex(0, "fonttbl")
 }
 
 /**
  * check if the group define the stylesheet
  */
-func (r *rtfGroup) IsStylesheet() bool {
+func (r *Group) IsStylesheet() bool {
 	return r.CheckChildAtIndex(0, "stylesheet")
 }
 
 /**
- * check if the group define the listtables
+ * check if the group define the list table: {\*\listtable ...}
  */
-func (r *rtfGroup) IsListtables() bool {
-	return r.CheckChildAtIndex(0, "listtables")
+func (r *Group) IsListtables() bool {
+	return r.CheckChildAtIndex(0, "listtable") || (r.IsDestination() && r.CheckChildAtIndex(1, "listtable"))
 }
 
 /**
  * check if info group - document info are
  */
-func (r *rtfGroup) IsInfo() bool {
+func (r *Group) IsInfo() bool {
 	return r.CheckChildAtIndex(0, "info")
 }
 
 /**
  * check if the group define the files table
  */
-func (r *rtfGroup) IsFilesTable() bool {
+func (r *Group) IsFilesTable() bool {
 	return (r.IsDestination() && r.CheckChildAtIndex(1, "filetbl"))
 }
 
 /**
  * check if the group define the revtbl
  */
-func (r *rtfGroup) IsTrackChanges() bool {
+func (r *Group) IsTrackChanges() bool {
 	return (r.IsDestination() && r.CheckChildAtIndex(1, "revtbl")) || r.CheckChildAtIndex(0, "revtbl")
 }
 
@@ -96,7 +102,7 @@ func (r *rtfGroup) IsTrackChanges() bool {
 /**
  * check if the group define the font table (first child must be colortbl)
  */
-func (r *rtfGroup) IsColorTable() bool {
+func (r *Group) IsColorTable() bool {
 	return r.CheckChildAtIndex(0, "colortbl")
 }
 
@@ -106,7 +112,7 @@ func (r *rtfGroup) IsColorTable() bool {
  * eq:
  * 	{\f0\fswiss\fcharset0 Arial;}
  */
-func (r *rtfGroup) IsFontInfo() bool {
+func (r *Group) IsFontInfo() bool {
 	return r.CheckChildAtIndex(0, "f")
 }
 
@@ -115,29 +121,87 @@ func (r *rtfGroup) IsFontInfo() bool {
  * check if the group is an alternative name
  * {\*\falt xxxx}
  */
-func (r *rtfGroup) IsFontAlternative() bool {
+func (r *Group) IsFontAlternative() bool {
 	return r.IsDestination() && r.CheckChildAtIndex(1, "falt")
 }
 
 
 
-func (r *rtfGroup) CheckChildAtIndex(idx int, checkWord string)  (bool) {
+/**
+ * destinations that do not have visible document text, even if they are not marked with \*
+ */
+var rtfSkippedDestinations map[string]bool = map[string]bool{
+	"fonttbl":            true,
+	"colortbl":           true,
+	"stylesheet":         true,
+	"info":               true,
+	"listtable":          true,
+	"listoverridetable":  true,
+	"listtext":           true,
+	"pntext":             true,
+	"filetbl":            true,
+	"revtbl":             true,
+	"rsidtbl":            true,
+	"generator":          true,
+	"pict":               true,
+	"nonshppict":         true,
+	"header":             true,
+	"headerl":            true,
+	"headerr":            true,
+	"headerf":            true,
+	"footer":             true,
+	"footerl":            true,
+	"footerr":            true,
+	"footerf":            true,
+	"footnote":           true,
+	"xe":                 true,
+	"tc":                 true,
+	"txe":                true,
+	"rxe":                true,
+	"bkmkstart":          true,
+	"bkmkend":            true,
+	"objdata":            true,
+	"objclass":           true,
+	"themedata":          true,
+	"colorschememapping": true,
+	"latentstyles":       true,
+	"datastore":          true,
+}
+
+/**
+ * check if the group is a destination that must not be rendered as document text:
+ * an optional destination (\*\word) or one of the known non text destinations
+ */
+func (r *Group) IsSkippedDestination() bool {
+	if r.IsDestination() {
+		return true
+	}
+
+	if len(r.children) > 0 {
+		if word, ok := r.children[0].(*ControlWord); ok {
+			return rtfSkippedDestinations[word.word]
+		}
+	}
+	return false
+}
+
+func (r *Group) CheckChildAtIndex(idx int, checkWord string)  (bool) {
 
 	if idx < len(r.children) {
 		child := r.children[idx]
 	    // First child not a control symbol?
 	    switch child.(type) {
-	    	case *rtfControlSymbol:
-	    		return child.(*rtfControlSymbol).symbol == checkWord
-	    	case *rtfControlWord:
-	    		return child.(*rtfControlWord).word == checkWord
+	    	case *ControlSymbol:
+	    		return child.(*ControlSymbol).symbol == checkWord
+	    	case *ControlWord:
+	    		return child.(*ControlWord).word == checkWord
 	    }
 	}
 	return false;
 }
 
 
-func (r *rtfGroup) Dump(level int) {
+func (r *Group) Dump(level int) {
 	fmt.Printf("%sGroup (Children: %d)\r\n", strings.Repeat(" ", level), len(r.children));
 	if len(r.children) > 0 {
 		for _, child := range(r.children) {
@@ -157,39 +221,39 @@ func (r *rtfGroup) Dump(level int) {
  * 	 parameter: 1
  *
  */
-type rtfControlWord struct {
+type ControlWord struct {
 	word string
 	parameter string
-	parent *rtfGroup
+	parent *Group
 }
 
 
-func (r *rtfControlWord) setParent(p *rtfGroup) {
+func (r *ControlWord) setParent(p *Group) {
 	r.parent = p
 }
 
-func (r *rtfControlWord) GetParent()(*rtfGroup) {
+func (r *ControlWord) GetParent()(*Group) {
 	return r.parent
 }
 
-func (r *rtfControlWord) GetWord()(string) {
+func (r *ControlWord) GetWord()(string) {
 	return r.word
 }
 
 
 /**
  * parameter should be an integer; I return it as a string so I can make the difference between 0 and empty
- * @param  {[type]} r *rtfControlWord) GetParameter() (string [description]
+ * @param  {[type]} r *ControlWord) GetParameter() (string [description]
  * @return {[type]}   [description]
  */
-func (r *rtfControlWord) GetParameter() (string) {
+func (r *ControlWord) GetParameter() (string) {
 	return r.parameter
 }
 
 /**
  * for control words the default parameter is 1 and is integer
  */
-func (r *rtfControlWord) GetIntParameter() (int) {
+func (r *ControlWord) GetIntParameter() (int) {
 	if (r.parameter == "") {
 		return 1
 	}
@@ -200,7 +264,14 @@ func (r *rtfControlWord) GetIntParameter() (int) {
 	return 1
 }
 
-func (r *rtfControlWord) Dump(level int) {
+/**
+ * toggle control words (\b, \i, ...) are turned off by the parameter 0; without parameter or with any other value are turned on
+ */
+func (r *ControlWord) GetFlagParameter() (bool) {
+	return r.parameter != "0"
+}
+
+func (r *ControlWord) Dump(level int) {
 	fmt.Printf("%sControl Word (Word: \\%s%v)\r\n", strings.Repeat(" ", level), r.word, r.parameter);
 }
 
@@ -213,34 +284,34 @@ func (r *rtfControlWord) Dump(level int) {
  * 		symbol: '
  * 		parameter: HH
  */
-type rtfControlSymbol struct {
+type ControlSymbol struct {
 	symbol string
 	parameter string
-	parent *rtfGroup
+	parent *Group
 }
 
-func (r *rtfControlSymbol) GetSymbol()(string) {
+func (r *ControlSymbol) GetSymbol()(string) {
 	return r.symbol
 }
 
-func (r *rtfControlSymbol) setParent(p *rtfGroup) {
+func (r *ControlSymbol) setParent(p *Group) {
 	r.parent = p
 }
 
-func (r *rtfControlSymbol) GetParent()(*rtfGroup) {
+func (r *ControlSymbol) GetParent()(*Group) {
 	return r.parent
 }
 
 /**
  * usually the parameter is empty, except when the control symbol is \'
- * @param  {[type]} r *rtfControlSymbol) GetParameter() (string [description]
+ * @param  {[type]} r *ControlSymbol) GetParameter() (string [description]
  * @return {[type]}   [description]
  */
-func (r *rtfControlSymbol) GetParameter() (string) {
+func (r *ControlSymbol) GetParameter() (string) {
 	return r.parameter
 }
 
-func (r *rtfControlSymbol) Dump(level int) {
+func (r *ControlSymbol) Dump(level int) {
 	fmt.Printf("%sControl Symbol (Symbol: \\%s%s)\r\n", strings.Repeat(" ", level), r.symbol, r.parameter);
 }
 
@@ -248,24 +319,49 @@ func (r *rtfControlSymbol) Dump(level int) {
 /**
  * Text
  */
-type rtfText struct {
+type Text struct {
 	content []byte
-	parent *rtfGroup
+	parent *Group
 }
 
 
-func (r *rtfText) setParent(p *rtfGroup) {
+func (r *Text) setParent(p *Group) {
 	r.parent = p
 }
 
-func (r *rtfText) GetParent()(*rtfGroup) {
+func (r *Text) GetParent()(*Group) {
 	return r.parent
 }
 
-func (r *rtfText) GetContent()([]byte) {
+func (r *Text) GetContent()([]byte) {
 	return r.content
 }
 
-func (r *rtfText) Dump(level int) {
+func (r *Text) Dump(level int) {
 	fmt.Printf("%sControl Text: %s)\r\n", strings.Repeat(" ", level), r.content);
 }
+
+
+/**
+ * binary data (\binN): N bytes that follow the control word are not RTF text
+ */
+type Binary struct {
+	data []byte
+	parent *Group
+}
+
+func (r *Binary) setParent(p *Group) {
+	r.parent = p
+}
+
+func (r *Binary) GetParent()(*Group) {
+	return r.parent
+}
+
+func (r *Binary) GetData()([]byte) {
+	return r.data
+}
+
+func (r *Binary) Dump(level int) {
+	fmt.Printf("%sBinary (Bytes: %d)\r\n", strings.Repeat(" ", level), len(r.data));
+}
@@ -0,0 +1,337 @@
+/**
+ * build, modify and navigate the tree of a RTF document
+ */
+
+package rtfconverter
+
+import (
+	"bytes"
+	"errors"
+)
+
+/**
+ * create a group with the children
+ */
+func NewGroup(children ...Element) *Group {
+	group := &Group{}
+	for _, child := range children {
+		group.AppendChild(child)
+	}
+	return group
+}
+
+/**
+ * create a destination group: {\*\word children} if optional, {\word children} otherwise
+ */
+func NewDestinationGroup(word string, optional bool, children ...Element) *Group {
+	group := &Group{}
+	if optional {
+		group.AppendChild(NewControlSymbol("*", ""))
+	}
+	group.AppendChild(NewControlWord(word, ""))
+	for _, child := range children {
+		group.AppendChild(child)
+	}
+	return group
+}
+
+/**
+ * create a control word; the word is without \ and the parameter is empty or a (signed) number
+ * eg: NewControlWord("fs", "24") - \fs24
+ */
+func NewControlWord(word string, parameter string) *ControlWord {
+	return &ControlWord{word: word, parameter: parameter}
+}
+
+/**
+ * create a control symbol; the symbol is without \ and the parameter is used only by \'HH
+ * eg: NewControlSymbol("'", "e9") - \'e9
+ */
+func NewControlSymbol(symbol string, parameter string) *ControlSymbol {
+	return &ControlSymbol{symbol: symbol, parameter: parameter}
+}
+
+/**
+ * create a text; the text is in the document code page and the RTF delimiters ({, } and \) are escaped
+ */
+func NewText(text []byte) *Text {
+	content := make([]byte, 0, len(text))
+	for _, b := range text {
+		if b == '{' || b == '}' || b == '\\' {
+			content = append(content, '\\')
+		}
+		content = append(content, b)
+	}
+	return &Text{content: content}
+}
+
+/**
+ * create a binary data (\binN)
+ */
+func NewBinary(data []byte) *Binary {
+	return &Binary{data: data}
+}
+
+/**
+ * the text without the escape chars of the RTF delimiters
+ */
+func (r *Text) GetText() []byte {
+	return UnescapeRtfText(r.content)
+}
+
+func (r *Text) SetText(text []byte) {
+	r.content = NewText(text).content
+}
+
+func (r *ControlWord) SetParameter(parameter string) {
+	r.parameter = parameter
+}
+
+func (r *ControlSymbol) SetParameter(parameter string) {
+	r.parameter = parameter
+}
+
+func (r *Binary) SetData(data []byte) {
+	r.data = data
+}
+
+/**
+ * the number of children
+ */
+func (r *Group) ChildCount() int {
+	return len(r.children)
+}
+
+/**
+ * the child at index; nil if the index is out of range
+ */
+func (r *Group) ChildAt(idx int) Element {
+	if idx < 0 || idx >= len(r.children) {
+		return nil
+	}
+	return r.children[idx]
+}
+
+/**
+ * the index of the child; -1 if the element is not a child of the group
+ */
+func (r *Group) IndexOf(c Element) int {
+	for i, child := range r.children {
+		if child == c {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *Group) FirstChild() Element {
+	return r.ChildAt(0)
+}
+
+func (r *Group) LastChild() Element {
+	return r.ChildAt(len(r.children) - 1)
+}
+
+/**
+ * add the element as the last child; an element that has a parent is moved from its parent
+ */
+func (r *Group) AppendChild(c Element) error {
+	return r.InsertChild(len(r.children), c)
+}
+
+/**
+ * insert the element before the child at index (len - add as the last child); an element that has a parent is moved from its parent
+ */
+func (r *Group) InsertChild(idx int, c Element) error {
+	if idx < 0 || idx > len(r.children) {
+		return errors.New("The child index is out of range.")
+	}
+	if group, ok := c.(*Group); ok && group.contains(r) {
+		return errors.New("A group can not be added to itself.")
+	}
+
+	if c.GetParent() == r && r.IndexOf(c) < idx {
+		// the index is after the element, that is removed first
+		idx--
+	}
+	detachElement(c)
+
+	r.children = append(r.children, nil)
+	copy(r.children[idx+1:], r.children[idx:])
+	r.children[idx] = c
+	c.setParent(r)
+
+	return nil
+}
+
+/**
+ * remove the child; return false if the element is not a child of the group
+ */
+func (r *Group) RemoveChild(c Element) bool {
+	idx := r.IndexOf(c)
+	if idx < 0 {
+		return false
+	}
+
+	r.children = append(r.children[:idx], r.children[idx+1:]...)
+	c.setParent(nil)
+	return true
+}
+
+/**
+ * replace the child with the new element; return false if the old element is not a child of the group
+ */
+func (r *Group) ReplaceChild(old Element, c Element) bool {
+	idx := r.IndexOf(old)
+	if idx < 0 {
+		return false
+	}
+	if old == c {
+		return true
+	}
+
+	r.RemoveChild(old)
+	return r.InsertChild(idx, c) == nil
+}
+
+/**
+ * check if the group is the element or one of its ancestors
+ */
+func (r *Group) contains(e Element) bool {
+	for {
+		if group, ok := e.(*Group); ok && group == r {
+			return true
+		}
+
+		parent := e.GetParent()
+		if parent == nil {
+			return false
+		}
+		e = parent
+	}
+}
+
+/**
+ * remove the element from its parent
+ */
+func detachElement(c Element) {
+	if parent := c.GetParent(); parent != nil {
+		parent.RemoveChild(c)
+	}
+}
+
+/**
+ * the sibling at offset from the element; nil if the element has no parent or there is no sibling
+ */
+func elementSibling(c Element, offset int) Element {
+	parent := c.GetParent()
+	if parent == nil {
+		return nil
+	}
+
+	idx := parent.IndexOf(c)
+	if idx < 0 {
+		return nil
+	}
+	return parent.ChildAt(idx + offset)
+}
+
+func (r *Group) NextSibling() Element {
+	return elementSibling(r, 1)
+}
+
+func (r *Group) PreviousSibling() Element {
+	return elementSibling(r, -1)
+}
+
+func (r *ControlWord) NextSibling() Element {
+	return elementSibling(r, 1)
+}
+
+func (r *ControlWord) PreviousSibling() Element {
+	return elementSibling(r, -1)
+}
+
+func (r *ControlSymbol) NextSibling() Element {
+	return elementSibling(r, 1)
+}
+
+func (r *ControlSymbol) PreviousSibling() Element {
+	return elementSibling(r, -1)
+}
+
+func (r *Text) NextSibling() Element {
+	return elementSibling(r, 1)
+}
+
+func (r *Text) PreviousSibling() Element {
+	return elementSibling(r, -1)
+}
+
+func (r *Binary) NextSibling() Element {
+	return elementSibling(r, 1)
+}
+
+func (r *Binary) PreviousSibling() Element {
+	return elementSibling(r, -1)
+}
+
+/**
+ * called for each element of the tree, in document order; a group is visited before its children
+ * returning SkipGroup for a group skips its children, returning any other error stops the walk
+ */
+type WalkFunc func(item Element) error
+
+/**
+ * visit the group and all its descendants
+ * the children of a group are read before they are visited, so the visitor may modify the group
+ */
+func (r *Group) Walk(visitor WalkFunc) error {
+	err := visitor(r)
+	if errors.Is(err, SkipGroup) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	children := append([]Element(nil), r.children...)
+	for _, child := range children {
+		if group, ok := child.(*Group); ok {
+			err = group.Walk(visitor)
+		} else {
+			err = visitor(child)
+			if errors.Is(err, SkipGroup) {
+				err = nil
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+ * visit all the elements of the document
+ */
+func (rtfObj *RtfStructure) Walk(visitor WalkFunc) error {
+	if rtfObj.Root == nil {
+		return nil
+	}
+	return rtfObj.Root.Walk(visitor)
+}
+
+/**
+ * the text of the group and of its descendants, without the escape chars (the text of \'HH, \uN, etc is not included)
+ */
+func (r *Group) GetText() []byte {
+	buffer := bytes.Buffer{}
+	r.Walk(func(item Element) error {
+		if text, ok := item.(*Text); ok {
+			buffer.Write(text.GetText())
+		}
+		return nil
+	})
+	return buffer.Bytes()
+}
@@ -0,0 +1,174 @@
+package rtfconverter
+
+import (
+	"errors"
+	"testing"
+)
+
+func writeRtfTree(t *testing.T, root *Group) string {
+	t.Helper()
+
+	rtfObj := RtfStructure{Root: root}
+	result, err := rtfObj.Bytes()
+	if err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	return string(result)
+}
+
+func TestDomConstruction(t *testing.T) {
+	root := NewGroup(
+		NewControlWord("rtf", "1"),
+		NewDestinationGroup("generator", true, NewText([]byte("gen;"))),
+		NewControlWord("b", ""),
+		NewText([]byte("a{b}\\c")),
+		NewControlSymbol("'", "e9"),
+		NewControlWord("b", "0"),
+		NewGroup(NewControlWord("pict", ""), NewBinary([]byte{'{', 0})),
+	)
+
+	rtf := writeRtfTree(t, root)
+
+	var rtfObj RtfStructure
+	if err := rtfObj.ParseBytes([]byte(rtf)); err != nil {
+		t.Fatalf("parse of %q failed: %v", rtf, err)
+	}
+	if got := writeRtfTree(t, rtfObj.Root); got != rtf {
+		t.Fatalf("the parsed tree is written as %q, expected %q", got, rtf)
+	}
+
+	generator := rtfObj.FindDestination("generator")
+	if generator == nil || string(generator.GetText()) != "gen;" {
+		t.Fatalf("generator group not found in %q", rtf)
+	}
+	if word, optional := generator.GetDestination(); word == nil || word.GetWord() != "generator" || !optional {
+		t.Fatalf("wrong destination of the generator group: %v %v", word, optional)
+	}
+
+	text, _ := rtfObj.Root.ChildAt(3).(*Text)
+	if text == nil || string(text.GetText()) != "a{b}\\c" {
+		t.Fatalf("the escaped text is not read back: %v", rtfObj.Root.ChildAt(3))
+	}
+}
+
+func TestDomMutation(t *testing.T) {
+	first := NewText([]byte("first"))
+	second := NewText([]byte("second"))
+	word := NewControlWord("b", "")
+	inner := NewGroup(word)
+	root := NewGroup(first, inner, second)
+
+	if root.ChildCount() != 3 || root.FirstChild() != first || root.LastChild() != second {
+		t.Fatalf("wrong children after construction")
+	}
+	if inner.PreviousSibling() != first || inner.NextSibling() != second || first.PreviousSibling() != nil || second.NextSibling() != nil {
+		t.Fatalf("wrong siblings")
+	}
+	if word.GetParent() != inner || inner.GetParent() != root || root.GetParent() != nil {
+		t.Fatalf("wrong parents")
+	}
+
+	// an element with a parent is moved
+	if err := inner.AppendChild(first); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if root.ChildCount() != 2 || root.IndexOf(first) != -1 || inner.IndexOf(first) != 1 || first.GetParent() != inner {
+		t.Fatalf("the element is not moved")
+	}
+
+	// moved inside the same group, after its own position
+	if err := root.InsertChild(2, inner); err != nil {
+		t.Fatalf("insert failed: %v", err)
+	}
+	if root.ChildAt(0) != second || root.ChildAt(1) != inner {
+		t.Fatalf("wrong order after the move: %q", writeRtfTree(t, root))
+	}
+
+	replacement := NewControlWord("i", "")
+	if !inner.ReplaceChild(word, replacement) || word.GetParent() != nil || inner.ChildAt(0) != replacement {
+		t.Fatalf("the child is not replaced")
+	}
+	replacement.SetParameter("0")
+	first.SetText([]byte("x}"))
+
+	if got := writeRtfTree(t, root); got != "{second{\\i0 x\\}}}" {
+		t.Fatalf("got %q", got)
+	}
+
+	if !root.RemoveChild(second) || second.GetParent() != nil || root.ChildCount() != 1 {
+		t.Fatalf("the child is not removed")
+	}
+
+	// errors
+	if root.RemoveChild(second) || root.ReplaceChild(second, word) {
+		t.Fatalf("an element that is not a child was removed or replaced")
+	}
+	if root.ChildAt(5) != nil || root.ChildAt(-1) != nil {
+		t.Fatalf("a child out of range was returned")
+	}
+	if err := root.InsertChild(5, second); err == nil {
+		t.Fatalf("insert out of range succeeded")
+	}
+	if err := inner.AppendChild(root); err == nil {
+		t.Fatalf("a group was added to its descendant")
+	}
+	if err := inner.AppendChild(inner); err == nil {
+		t.Fatalf("a group was added to itself")
+	}
+	if inner.GetParent() != root || root.GetParent() != nil {
+		t.Fatalf("a failed insert changed the tree")
+	}
+}
+
+func TestDomWalk(t *testing.T) {
+	var rtfObj RtfStructure
+	if err := rtfObj.ParseBytes([]byte("{\\rtf1 a{\\*\\skip b}{c{d}}e}")); err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	var texts string
+	err := rtfObj.Walk(func(item Element) error {
+		if group, ok := item.(*Group); ok && group.IsDestination() {
+			return SkipGroup
+		}
+		if text, ok := item.(*Text); ok {
+			texts += string(text.GetText())
+		}
+		return nil
+	})
+	if err != nil || texts != "acde" {
+		t.Fatalf("walk returned %q, %v", texts, err)
+	}
+
+	// any other error stops the walk
+	stop := errors.New("stop")
+	texts = ""
+	err = rtfObj.Walk(func(item Element) error {
+		if text, ok := item.(*Text); ok {
+			texts += string(text.GetText())
+			if texts == "ab" {
+				return stop
+			}
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || texts != "ab" {
+		t.Fatalf("walk returned %q, %v", texts, err)
+	}
+
+	// the visitor may remove the visited children
+	rtfObj.Walk(func(item Element) error {
+		if text, ok := item.(*Text); ok {
+			text.GetParent().RemoveChild(text)
+		}
+		return nil
+	})
+	if got := string(rtfObj.Root.GetText()); got != "" {
+		t.Fatalf("texts left after removal: %q", got)
+	}
+
+	var empty RtfStructure
+	if err := empty.Walk(func(item Element) error { return stop }); err != nil {
+		t.Fatalf("walk of an empty document returned %v", err)
+	}
+}
@@ -0,0 +1,243 @@
+/**
+ * parse the fields of the document: {\field {\*\fldinst <instruction>} {\fldrslt <result>}}
+ * the instruction is the field type followed by the arguments and the switches, eg: HYPERLINK "https://example.com" \o "tooltip"
+ */
+
+package rtfconverter
+
+import (
+	"strings"
+)
+
+/**
+ * a switch of the field instruction (eg: \l "bookmark", \* MERGEFORMAT); the name is without \
+ */
+type RtfFieldSwitch struct {
+	Name  string
+	Value string
+}
+
+type RtfField struct {
+	// the field type in upper case (HYPERLINK, PAGE, DATE, INCLUDEPICTURE, etc)
+	Type string
+
+	// the entire instruction, as it is written in \fldinst
+	Instruction string
+
+	// the arguments that are not switch values, without quotes
+	Arguments []string
+	Switches  []RtfFieldSwitch
+
+	// the text of \fldrslt: the last computed value of the field
+	Result string
+}
+
+/**
+ * all the fields of the document, in document order (a nested field follows the field that contains it)
+ */
+func (rtfObj *RtfStructure) Fields() ([]RtfField, error) {
+	var fields []RtfField
+
+	if !rtfObj.IsValid() {
+		return nil, ErrNotRTF
+	}
+
+	encoding := documentEncoding(rtfObj.Root)
+
+	rtfObj.Root.Walk(func(item Element) error {
+		if group, ok := item.(*Group); ok && group.IsField() {
+			fields = append(fields, extractField(group, encoding))
+		}
+		return nil
+	})
+
+	return fields, nil
+}
+
+/**
+ * check if the group is a field {\field ...}
+ */
+func (r *Group) IsField() bool {
+	return r.CheckChildAtIndex(0, "field")
+}
+
+/**
+ * check if the group is the instruction of a field: {\*\fldinst ...} or {\fldinst ...}
+ */
+func (r *Group) IsFieldInstruction() bool {
+	return r.CheckChildAtIndex(0, "fldinst") || (r.IsDestination() && r.CheckChildAtIndex(1, "fldinst"))
+}
+
+/**
+ * check if the group is the result of a field {\fldrslt ...}
+ */
+func (r *Group) IsFieldResult() bool {
+	return r.CheckChildAtIndex(0, "fldrslt")
+}
+
+func extractField(item *Group, encoding string) RtfField {
+	field := RtfField{}
+
+	for _, child := range item.GetChildren() {
+		group, ok := child.(*Group)
+		if !ok {
+			continue
+		}
+
+		if group.IsFieldInstruction() {
+			result := field.Result
+			field = ParseFieldInstruction(decodeGroupText(group, encoding))
+			field.Result = result
+		} else if group.IsFieldResult() {
+			field.Result = decodeGroupText(group, encoding)
+		}
+	}
+
+	return field
+}
+
+/**
+ * split the instruction in the field type, the arguments and the switches
+ * the arguments with spaces are quoted; inside the quotes \" is a quote and \\ is a backslash
+ * a switch value is the argument that follows the switch, if it is not another switch
+ */
+func ParseFieldInstruction(instruction string) RtfField {
+	field := RtfField{Instruction: instruction}
+
+	var switchIdx = -1
+	for i, token := range splitFieldInstruction(instruction) {
+		switch {
+		case i == 0 && !token.quoted:
+			field.Type = strings.ToUpper(token.text)
+		case !token.quoted && strings.HasPrefix(token.text, "\\") && len(token.text) > 1:
+			field.Switches = append(field.Switches, RtfFieldSwitch{Name: token.text[1:]})
+			switchIdx = len(field.Switches) - 1
+		case switchIdx >= 0:
+			field.Switches[switchIdx].Value = token.text
+			switchIdx = -1
+		default:
+			field.Arguments = append(field.Arguments, token.text)
+		}
+	}
+
+	return field
+}
+
+type rtfFieldToken struct {
+	text   string
+	quoted bool
+}
+
+func splitFieldInstruction(instruction string) []rtfFieldToken {
+	var (
+		tokens  []rtfFieldToken
+		current strings.Builder
+		inToken bool
+		quoted  bool
+	)
+
+	endToken := func() {
+		if inToken {
+			tokens = append(tokens, rtfFieldToken{text: current.String(), quoted: quoted})
+		}
+		current.Reset()
+		inToken = false
+		quoted = false
+	}
+
+	for i := 0; i < len(instruction); i++ {
+		c := instruction[i]
+
+		switch {
+		case quoted:
+			if c == '\\' && i+1 < len(instruction) && (instruction[i+1] == '"' || instruction[i+1] == '\\') {
+				i++
+				current.WriteByte(instruction[i])
+			} else if c == '"' {
+				endToken()
+			} else {
+				current.WriteByte(c)
+			}
+		case c == '"':
+			endToken()
+			inToken = true
+			quoted = true
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			endToken()
+		default:
+			inToken = true
+			current.WriteByte(c)
+		}
+	}
+	endToken()
+
+	return tokens
+}
+
+/**
+ * the value of the switch (without \) and if the switch is present
+ */
+func (f RtfField) Switch(name string) (string, bool) {
+	for _, s := range f.Switches {
+		if s.Name == name {
+			return s.Value, true
+		}
+	}
+	return "", false
+}
+
+/**
+ * the target of a HYPERLINK field (the url and the \l bookmark) or of a MAILTO field; empty for the other fields
+ */
+func (f RtfField) URL() string {
+	url := ""
+	if len(f.Arguments) > 0 {
+		url = f.Arguments[0]
+	}
+
+	switch f.Type {
+	case "HYPERLINK":
+		if bookmark, ok := f.Switch("l"); ok && bookmark != "" {
+			url += "#" + bookmark
+		}
+		return url
+	case "MAILTO":
+		if url != "" && !strings.HasPrefix(strings.ToLower(url), "mailto:") {
+			url = "mailto:" + url
+		}
+		return url
+	}
+	return ""
+}
+
+/**
+ * the schemes of the links written in the html; the links without scheme (relative urls and #bookmarks) are written too
+ */
+var rtfSafeLinkSchemes map[string]bool = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+	"ftp":    true,
+}
+
+/**
+ * only the links with an allowed scheme are written in the html
+ * the browsers drop the control chars and the whitespaces from the urls (eg: java\tscript:), so they are dropped
+ * before the scheme is checked
+ */
+func isSafeLinkURL(url string) bool {
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, url)
+
+	end := strings.IndexAny(cleaned, ":/?#")
+	if end < 0 || cleaned[end] != ':' {
+		// a relative url or a bookmark
+		return true
+	}
+
+	return rtfSafeLinkSchemes[strings.ToLower(cleaned[:end])]
+}
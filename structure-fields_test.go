@@ -0,0 +1,35 @@
+package rtfconverter
+
+import (
+	"testing"
+)
+
+func TestIsSafeLinkURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		safe bool
+	}{
+		{"http://example.com/", true},
+		{"HTTPS://example.com/a?b=c:d", true},
+		{"mailto:user@example.com", true},
+		{"ftp://example.com/file", true},
+		{"#bookmark", true},
+		{"page.html#top", true},
+		{"/path/to:page", true},
+		{"javascript:alert(1)", false},
+		{"JavaScript:alert(1)", false},
+		{"java\tscript:alert(1)", false},
+		{"java\nscript:alert(1)", false},
+		{"java\rscript:alert(1)", false},
+		{"\x01\x02 javascript:alert(1)", false},
+		{" vbscript:msgbox(1)", false},
+		{"data:text/html;base64,PHNjcmlwdD4=", false},
+		{"file:///etc/passwd", false},
+	}
+
+	for _, test := range tests {
+		if got := isSafeLinkURL(test.url); got != test.safe {
+			t.Errorf("isSafeLinkURL(%q) = %v, expected %v", test.url, got, test.safe)
+		}
+	}
+}
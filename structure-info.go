@@ -0,0 +1,276 @@
+/**
+ * extract the document metadata from the \info group
+ */
+
+package rtfconverter
+
+import (
+	"bytes"
+	"strconv"
+	"time"
+	"unicode/utf16"
+)
+
+/**
+ * the document properties
+ * the RTF times do not have a time zone; they are returned as UTC times
+ */
+type RtfMetadata struct {
+	Title         string
+	Subject       string
+	Author        string
+	Manager       string
+	Company       string
+	Operator      string // the person who last made changes to the document
+	Category      string
+	Keywords      string
+	Comment       string // \comment - comments of the author, ignored by the RTF readers
+	DocComment    string // \doccomm - the comments of the document properties
+	HyperlinkBase string
+
+	Created  time.Time
+	Revised  time.Time
+	Printed  time.Time
+	BackedUp time.Time
+
+	Version              int
+	InternalVersion      int // \vern
+	EditingMinutes       int
+	Pages                int
+	Words                int
+	Characters           int
+	CharactersWithSpaces int
+	Id                   int
+}
+
+/**
+ * return the metadata of the document; the fields that are not in the \info group are empty
+ */
+func (rtfObj *RtfStructure) Metadata() (RtfMetadata, error) {
+	metadata := RtfMetadata{}
+
+	if !rtfObj.IsValid() {
+		return metadata, ErrNotRTF
+	}
+
+	encoding := documentEncoding(rtfObj.Root)
+
+	for _, child := range rtfObj.Root.GetChildren() {
+		if group, ok := child.(*Group); ok && group.IsInfo() {
+			metadata.parseInfoGroup(group, encoding)
+		}
+	}
+
+	return metadata, nil
+}
+
+func (m *RtfMetadata) parseInfoGroup(item *Group, encoding string) {
+	for _, child := range item.GetChildren() {
+		group, ok := child.(*Group)
+		if !ok {
+			continue
+		}
+
+		word, _ := group.GetDestination()
+		if word == nil {
+			continue
+		}
+
+		switch word.GetWord() {
+		case "title":
+			m.Title = decodeGroupText(group, encoding)
+		case "subject":
+			m.Subject = decodeGroupText(group, encoding)
+		case "author":
+			m.Author = decodeGroupText(group, encoding)
+		case "manager":
+			m.Manager = decodeGroupText(group, encoding)
+		case "company":
+			m.Company = decodeGroupText(group, encoding)
+		case "operator":
+			m.Operator = decodeGroupText(group, encoding)
+		case "category":
+			m.Category = decodeGroupText(group, encoding)
+		case "keywords":
+			m.Keywords = decodeGroupText(group, encoding)
+		case "comment":
+			m.Comment = decodeGroupText(group, encoding)
+		case "doccomm":
+			m.DocComment = decodeGroupText(group, encoding)
+		case "hlinkbase":
+			m.HyperlinkBase = decodeGroupText(group, encoding)
+		case "creatim":
+			m.Created = parseInfoTime(group)
+		case "revtim":
+			m.Revised = parseInfoTime(group)
+		case "printim":
+			m.Printed = parseInfoTime(group)
+		case "buptim":
+			m.BackedUp = parseInfoTime(group)
+		}
+	}
+
+	// the numeric properties are control words of the info group, or groups with a single control word
+	item.Walk(func(e Element) error {
+		if word, ok := e.(*ControlWord); ok {
+			m.parseInfoNumber(word)
+		}
+		return nil
+	})
+}
+
+func (m *RtfMetadata) parseInfoNumber(item *ControlWord) {
+	value, err := strconv.Atoi(item.GetParameter())
+	if err != nil {
+		return
+	}
+
+	switch item.GetWord() {
+	case "version":
+		m.Version = value
+	case "vern":
+		m.InternalVersion = value
+	case "edmins":
+		m.EditingMinutes = value
+	case "nofpages":
+		m.Pages = value
+	case "nofwords":
+		m.Words = value
+	case "nofchars":
+		m.Characters = value
+	case "nofcharsws":
+		m.CharactersWithSpaces = value
+	case "id":
+		m.Id = value
+	}
+}
+
+/**
+ * {\creatim\yrN\moN\dyN\hrN\minN\secN}; a zero time if the year is missing
+ */
+func parseInfoTime(item *Group) time.Time {
+	parts := map[string]int{"mo": 1, "dy": 1}
+	for _, child := range item.GetChildren() {
+		if word, ok := child.(*ControlWord); ok {
+			switch word.GetWord() {
+			case "yr", "mo", "dy", "hr", "min", "sec":
+				parts[word.GetWord()] = word.GetIntParameter()
+			}
+		}
+	}
+
+	if _, ok := parts["yr"]; !ok {
+		return time.Time{}
+	}
+	return time.Date(parts["yr"], time.Month(parts["mo"]), parts["dy"], parts["hr"], parts["min"], parts["sec"], 0, time.UTC)
+}
+
+/**
+ * the code page of the document (\ansi, \mac, \pc, \pca, \ansicpgN), from the root group
+ */
+func documentEncoding(root *Group) string {
+	encoding := "CP1252"
+	for _, child := range root.GetChildren() {
+		word, ok := child.(*ControlWord)
+		if !ok {
+			continue
+		}
+
+		switch word.GetWord() {
+		case "ansi", "mac", "pc", "pca":
+			if e, err := GetEncodingFromCodepage(word.GetWord()); err == nil {
+				encoding = e
+			}
+		case "ansicpg":
+			if e, err := GetEncodingFromCodepage(word.GetParameter()); err == nil {
+				encoding = e
+			}
+		}
+	}
+	return encoding
+}
+
+/**
+ * the text of a destination group (eg: {\title ...}) decoded to utf-8: the text and the \'HH bytes are in the
+ * document code page, the \uN chars are unicode
+ */
+func decodeGroupText(item *Group, encoding string) string {
+	var (
+		pendingBytes     []byte
+		pendingSurrogate rune
+	)
+	result := bytes.Buffer{}
+
+	flush := func() {
+		if len(pendingBytes) > 0 {
+			t, _ := ConvertToUtf8(pendingBytes, encoding)
+			result.Write(t)
+			pendingBytes = nil
+		}
+	}
+
+	item.Walk(func(e Element) error {
+		switch cobj := e.(type) {
+		case *Group:
+			if cobj != item && cobj.IsSkippedDestination() {
+				// a nested destination (eg: {\*\datafield ...}) is not part of the text
+				return SkipGroup
+			}
+		case *Text:
+			pendingBytes = append(pendingBytes, cobj.GetText()...)
+		case *ControlSymbol:
+			switch cobj.GetSymbol() {
+			case "'":
+				if v, err := strconv.ParseUint(cobj.GetParameter(), 16, 8); err == nil {
+					pendingBytes = append(pendingBytes, byte(v))
+				}
+			case "{", "}", "\\":
+				pendingBytes = append(pendingBytes, cobj.GetSymbol()...)
+			case "~":
+				flush()
+				result.WriteString(" ")
+			case "_":
+				flush()
+				result.WriteString("-")
+			}
+		case *ControlWord:
+			flush()
+			switch cobj.GetWord() {
+			case "u":
+				r, err := RuneFromUnicodeParameter(cobj.GetParameter())
+				if err != nil {
+					break
+				}
+				if r >= 0xd800 && r < 0xdc00 {
+					pendingSurrogate = r
+					break
+				}
+				if pendingSurrogate != 0 {
+					r = utf16.DecodeRune(pendingSurrogate, r)
+					pendingSurrogate = 0
+				}
+				result.WriteRune(r)
+			case "tab":
+				result.WriteString("\t")
+			case "lquote":
+				result.WriteString("‘")
+			case "rquote":
+				result.WriteString("’")
+			case "ldblquote":
+				result.WriteString("“")
+			case "rdblquote":
+				result.WriteString("”")
+			case "bullet":
+				result.WriteString("•")
+			case "endash":
+				result.WriteString("–")
+			case "emdash":
+				result.WriteString("—")
+			}
+		}
+		return nil
+	})
+	flush()
+
+	return result.String()
+}
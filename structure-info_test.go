@@ -0,0 +1,75 @@
+package rtfconverter
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMetadata(t *testing.T) {
+	rtf := "{\\rtf1\\ansi\\ansicpg1251{\\info" +
+		"{\\title \\'cf\\'f0\\'e8\\'e2\\'e5\\'f2}" +
+		"{\\author A \\{B\\} \\u8364?\\u-10179?\\u-8704?}" +
+		"{\\operator op}{\\keywords a\\tab b}{\\subject \\lquote s\\rquote }" +
+		"{\\doccomm doc{\\*\\datafield 0011} comment}{\\*\\company corp}" +
+		"{\\creatim\\yr2024\\mo2\\dy29\\hr13\\min5\\sec9}{\\revtim\\yr2025}{\\printim\\mo3\\dy1}" +
+		"{\\version3}{\\edmins12}\\nofpages2\\nofwords-1\\nofchars{\\vern0}}" +
+		"\\pard text\\par}"
+
+	var rtfObj RtfStructure
+	if err := rtfObj.ParseBytes([]byte(rtf)); err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	metadata, err := rtfObj.Metadata()
+	if err != nil {
+		t.Fatalf("metadata failed: %v", err)
+	}
+
+	expected := RtfMetadata{
+		Title:      "Привет",
+		Author:     "A {B} €😀",
+		Operator:   "op",
+		Keywords:   "a\tb",
+		Subject:    "‘s’",
+		DocComment: "doc comment",
+		Company:    "corp",
+
+		Created: time.Date(2024, time.February, 29, 13, 5, 9, 0, time.UTC),
+		Revised: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+
+		Version:        3,
+		EditingMinutes: 12,
+		Pages:          2,
+		Words:          -1,
+	}
+	if metadata != expected {
+		t.Fatalf("got %+v\nexpected %+v", metadata, expected)
+	}
+}
+
+func TestMetadataErrors(t *testing.T) {
+	// a document without \info has empty metadata
+	var rtfObj RtfStructure
+	if err := rtfObj.ParseBytes([]byte("{\\rtf1 text}")); err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if metadata, err := rtfObj.Metadata(); err != nil || metadata != (RtfMetadata{}) {
+		t.Fatalf("got %+v, %v", metadata, err)
+	}
+
+	// an \info group that is not a child of the root group is ignored
+	if err := rtfObj.ParseBytes([]byte("{\\rtf1{\\shp{\\info{\\title nested}}}}")); err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if metadata, err := rtfObj.Metadata(); err != nil || metadata.Title != "" {
+		t.Fatalf("got %+v, %v", metadata, err)
+	}
+
+	for _, content := range []string{"", "{\\info{\\title x}}", "{\\rtf2{\\info{\\title x}}}"} {
+		var invalid RtfStructure
+		invalid.ParseBytes([]byte(content))
+		if _, err := invalid.Metadata(); !errors.Is(err, ErrNotRTF) {
+			t.Fatalf("%q: expected %v, got %v", content, ErrNotRTF, err)
+		}
+	}
+}
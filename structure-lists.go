@@ -0,0 +1,391 @@
+/**
+ * extract the list definitions of the document: the lists of \listtable and the list overrides of \listoverridetable
+ * used by the paragraphs (\lsN\ilvlN); the lists of the old readers are defined by the paragraph (\pn)
+ *
+ * {\*\listtable {\list\listtemplateidN {\listlevel\levelnfcN\levelstartatN{\leveltext ...}{\levelnumbers ...}} ... \listidN}}
+ * {\*\listoverridetable {\listoverride\listidN\listoverridecountN {\lfolevel\listoverridestartat\levelstartatN}\lsN}}
+ */
+
+package rtfconverter
+
+import (
+	"strconv"
+	"strings"
+)
+
+type RtfListLevel struct {
+	// the number format as css list-style-type: decimal, upper-roman, lower-roman, upper-alpha, lower-alpha, bullet, none
+	Format string
+
+	// the number of the first item
+	Start int
+}
+
+type RtfList struct {
+	// the \listid of the list definition
+	Id     int
+	Levels []RtfListLevel
+}
+
+/**
+ * the \levelnfc number formats; the other formats (eg: the east asian ones) are written as decimal numbers
+ */
+var rtfListNumberFormats map[int]string = map[int]string{
+	0:   "decimal",
+	1:   "upper-roman",
+	2:   "lower-roman",
+	3:   "upper-alpha",
+	4:   "lower-alpha",
+	22:  "decimal",
+	23:  "bullet",
+	255: "none",
+}
+
+var rtfParagraphNumberFormats map[string]string = map[string]string{
+	"pndec":   "decimal",
+	"pncard":  "decimal",
+	"pnord":   "decimal",
+	"pnordt":  "decimal",
+	"pnucrm":  "upper-roman",
+	"pnlcrm":  "lower-roman",
+	"pnucltr": "upper-alpha",
+	"pnlcltr": "lower-alpha",
+}
+
+/**
+ * the list of the paragraphs defined with \pn; the lists of \listoverridetable are numbered from 1
+ */
+const rtfParagraphNumberingList = -1
+
+/**
+ * the lists used by the paragraphs of the document, by the \lsN number
+ */
+func (rtfObj *RtfStructure) Lists() (map[int]RtfList, error) {
+	if !rtfObj.IsValid() {
+		return nil, ErrNotRTF
+	}
+
+	var (
+		lists     map[int]RtfList
+		overrides map[int]RtfList
+	)
+
+	rtfObj.Root.Walk(func(item Element) error {
+		group, ok := item.(*Group)
+		if !ok {
+			return nil
+		}
+
+		if group.IsListtables() {
+			lists = extractListTable(group)
+			return SkipGroup
+		} else if group.IsListOverrideTable() {
+			overrides = extractListOverrideTable(group, lists)
+			return SkipGroup
+		}
+		return nil
+	})
+
+	if overrides == nil {
+		overrides = map[int]RtfList{}
+	}
+	return overrides, nil
+}
+
+/**
+ * check if the group is the list override table: {\*\listoverridetable ...}
+ */
+func (r *Group) IsListOverrideTable() bool {
+	return r.CheckChildAtIndex(0, "listoverridetable") || (r.IsDestination() && r.CheckChildAtIndex(1, "listoverridetable"))
+}
+
+/**
+ * check if the group defines the numbering of a paragraph for the old readers: {\*\pn ...}
+ */
+func (r *Group) IsParagraphNumbering() bool {
+	return r.CheckChildAtIndex(0, "pn") || (r.IsDestination() && r.CheckChildAtIndex(1, "pn"))
+}
+
+/**
+ * the lists of \listtable by \listid
+ */
+func extractListTable(item *Group) map[int]RtfList {
+	lists := map[int]RtfList{}
+
+	for _, child := range item.GetChildren() {
+		group, ok := child.(*Group)
+		if !ok || !group.CheckChildAtIndex(0, "list") {
+			continue
+		}
+
+		list := RtfList{}
+		for _, listChild := range group.GetChildren() {
+			switch cobj := listChild.(type) {
+			case *ControlWord:
+				if cobj.GetWord() == "listid" {
+					list.Id = cobj.GetIntParameter()
+				}
+			case *Group:
+				if cobj.CheckChildAtIndex(0, "listlevel") {
+					list.Levels = append(list.Levels, extractListLevel(cobj))
+				}
+			}
+		}
+		lists[list.Id] = list
+	}
+
+	return lists
+}
+
+func extractListLevel(item *Group) RtfListLevel {
+	level := RtfListLevel{Format: "decimal", Start: 1}
+
+	for _, child := range item.GetChildren() {
+		word, ok := child.(*ControlWord)
+		if !ok {
+			continue
+		}
+
+		switch word.GetWord() {
+		case "levelnfc", "levelnfcn":
+			if format, ok := rtfListNumberFormats[word.GetIntParameter()]; ok {
+				level.Format = format
+			} else {
+				level.Format = "decimal"
+			}
+		case "levelstartat":
+			level.Start = word.GetIntParameter()
+		}
+	}
+
+	return level
+}
+
+/**
+ * the lists of \listoverridetable by \ls; the start values changed by the override (\lfolevel\listoverridestartat) are applied
+ */
+func extractListOverrideTable(item *Group, lists map[int]RtfList) map[int]RtfList {
+	overrides := map[int]RtfList{}
+
+	for _, child := range item.GetChildren() {
+		group, ok := child.(*Group)
+		if !ok || !group.CheckChildAtIndex(0, "listoverride") {
+			continue
+		}
+
+		var (
+			list       RtfList
+			ls         int
+			startLevel []int
+		)
+
+		for _, overrideChild := range group.GetChildren() {
+			switch cobj := overrideChild.(type) {
+			case *ControlWord:
+				switch cobj.GetWord() {
+				case "listid":
+					list = lists[cobj.GetIntParameter()]
+					list.Id = cobj.GetIntParameter()
+				case "ls":
+					ls = cobj.GetIntParameter()
+				}
+			case *Group:
+				if cobj.CheckChildAtIndex(0, "lfolevel") {
+					startLevel = append(startLevel, extractOverrideStart(cobj))
+				}
+			}
+		}
+
+		// the levels are copied, the list definition is shared by several overrides
+		list.Levels = append([]RtfListLevel(nil), list.Levels...)
+		for idx, start := range startLevel {
+			if start >= 0 && idx < len(list.Levels) {
+				list.Levels[idx].Start = start
+			}
+		}
+
+		overrides[ls] = list
+	}
+
+	return overrides
+}
+
+/**
+ * the start value of an override level; -1 if the level does not override the start value
+ */
+func extractOverrideStart(item *Group) int {
+	overrideStart := false
+	start := -1
+
+	item.Walk(func(e Element) error {
+		if word, ok := e.(*ControlWord); ok {
+			switch word.GetWord() {
+			case "listoverridestartat":
+				overrideStart = true
+			case "levelstartat":
+				start = word.GetIntParameter()
+			}
+		}
+		return nil
+	})
+
+	if !overrideStart {
+		return -1
+	}
+	return start
+}
+
+/**
+ * the level and the numbering of a paragraph defined with {\*\pn ...}
+ */
+func extractParagraphNumbering(item *Group) (int, RtfListLevel) {
+	levelIdx := 0
+	level := RtfListLevel{Format: "decimal", Start: 1}
+
+	for _, child := range item.GetChildren() {
+		word, ok := child.(*ControlWord)
+		if !ok {
+			continue
+		}
+
+		switch word.GetWord() {
+		case "pnlvl":
+			levelIdx = word.GetIntParameter() - 1
+		case "pnlvlblt":
+			level.Format = "bullet"
+		case "pnstart":
+			level.Start = word.GetIntParameter()
+		default:
+			if format, ok := rtfParagraphNumberFormats[word.GetWord()]; ok {
+				level.Format = format
+			}
+		}
+	}
+
+	if levelIdx < 0 || levelIdx > 8 {
+		levelIdx = 0
+	}
+	return levelIdx, level
+}
+
+/**
+ * number the items of the lists while the paragraphs are converted
+ * the numbering of a list continues after the paragraphs that are not in the list; the numbering of the \pn
+ * paragraphs restarts
+ */
+type rtfListNumbering struct {
+	lists map[int]RtfList
+
+	// the last number of each level, by \ls
+	counters map[int][]int
+}
+
+func (n *rtfListNumbering) setLevel(ls int, levelIdx int, level RtfListLevel) {
+	if n.lists == nil {
+		n.lists = map[int]RtfList{}
+	}
+
+	list := n.lists[ls]
+	for len(list.Levels) <= levelIdx {
+		list.Levels = append(list.Levels, RtfListLevel{Format: "decimal", Start: 1})
+	}
+	list.Levels[levelIdx] = level
+	n.lists[ls] = list
+}
+
+/**
+ * the level of the list; an unknown level is a bulleted level
+ */
+func (n *rtfListNumbering) level(ls int, levelIdx int) RtfListLevel {
+	if list, ok := n.lists[ls]; ok && levelIdx >= 0 && levelIdx < len(list.Levels) {
+		return list.Levels[levelIdx]
+	}
+	return RtfListLevel{Format: "bullet", Start: 1}
+}
+
+/**
+ * the number of the next item of the level; the numbering of the deeper levels restarts
+ */
+func (n *rtfListNumbering) nextNumber(ls int, levelIdx int) int {
+	if n.counters == nil {
+		n.counters = map[int][]int{}
+	}
+
+	counters := n.counters[ls]
+	for len(counters) <= levelIdx {
+		counters = append(counters, 0)
+	}
+	counters = counters[:levelIdx+1]
+
+	if counters[levelIdx] == 0 {
+		counters[levelIdx] = n.level(ls, levelIdx).Start
+	} else {
+		counters[levelIdx]++
+	}
+	n.counters[ls] = counters
+
+	return counters[levelIdx]
+}
+
+/**
+ * a paragraph that is not in a list was converted
+ */
+func (n *rtfListNumbering) interrupt() {
+	delete(n.counters, rtfParagraphNumberingList)
+}
+
+/**
+ * the text of the item number: 1. a. iv. for the numbered levels, a bullet for the bulleted levels
+ */
+func formatListNumber(level RtfListLevel, number int) string {
+	switch level.Format {
+	case "bullet":
+		return "•"
+	case "none":
+		return ""
+	case "upper-roman":
+		return strings.ToUpper(romanNumber(number)) + "."
+	case "lower-roman":
+		return romanNumber(number) + "."
+	case "upper-alpha":
+		return strings.ToUpper(alphaNumber(number)) + "."
+	case "lower-alpha":
+		return alphaNumber(number) + "."
+	}
+	return strconv.Itoa(number) + "."
+}
+
+func romanNumber(number int) string {
+	if number <= 0 || number >= 4000 {
+		return strconv.Itoa(number)
+	}
+
+	values := []int{1000, 900, 500, 400, 100, 90, 50, 40, 10, 9, 5, 4, 1}
+	symbols := []string{"m", "cm", "d", "cd", "c", "xc", "l", "xl", "x", "ix", "v", "iv", "i"}
+
+	result := strings.Builder{}
+	for i, value := range values {
+		for number >= value {
+			result.WriteString(symbols[i])
+			number -= value
+		}
+	}
+	return result.String()
+}
+
+/**
+ * a, b, ..., z, aa, ab, ...
+ */
+func alphaNumber(number int) string {
+	if number <= 0 {
+		return strconv.Itoa(number)
+	}
+
+	result := ""
+	for number > 0 {
+		number--
+		result = string(rune('a'+number%26)) + result
+		number /= 26
+	}
+	return result
+}
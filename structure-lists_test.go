@@ -0,0 +1,145 @@
+package rtfconverter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLists(t *testing.T) {
+	rtf := "{\\rtf1{\\*\\listtable" +
+		"{\\list\\listtemplateid1{\\listlevel\\levelnfc4\\levelstartat3{\\leveltext\\'02\\'00.;}}{\\listlevel\\levelnfc23{\\leveltext\\'01\\u8226 ?;}}\\listid10}" +
+		"{\\list{\\listlevel\\levelnfc57\\levelstartat0}{\\listlevel\\levelnfcn255}\\listid20}}" +
+		"{\\*\\listoverridetable{\\listoverride\\listid10\\listoverridecount0\\ls1}" +
+		"{\\listoverride\\listid10\\listoverridecount2{\\lfolevel}{\\lfolevel\\listoverridestartat{\\listlevel\\levelstartat7}}\\ls2}" +
+		"{\\listoverride\\listid20\\listoverridecount1{\\lfolevel\\listoverridestartat\\levelstartat5}\\ls3}" +
+		"{\\listoverride\\listid99\\ls4}}" +
+		"\\pard\\ls1 item\\par}"
+
+	var rtfObj RtfStructure
+	if err := rtfObj.ParseBytes([]byte(rtf)); err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	lists, err := rtfObj.Lists()
+	if err != nil {
+		t.Fatalf("lists failed: %v", err)
+	}
+
+	expected := map[int]RtfList{
+		1: {Id: 10, Levels: []RtfListLevel{{"lower-alpha", 3}, {"bullet", 1}}},
+		// the override changes only the start of its own list
+		2: {Id: 10, Levels: []RtfListLevel{{"lower-alpha", 3}, {"bullet", 7}}},
+		// an unknown number format is decimal
+		3: {Id: 20, Levels: []RtfListLevel{{"decimal", 5}, {"none", 1}}},
+		// the list definition is missing
+		4: {Id: 99},
+	}
+	if !reflect.DeepEqual(lists, expected) {
+		t.Fatalf("got %+v\nexpected %+v", lists, expected)
+	}
+}
+
+func TestListsErrors(t *testing.T) {
+	var rtfObj RtfStructure
+	if err := rtfObj.ParseBytes([]byte("{\\rtf1\\pard\\ls1 item\\par}")); err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if lists, err := rtfObj.Lists(); err != nil || lists == nil || len(lists) != 0 {
+		t.Fatalf("got %v, %v", lists, err)
+	}
+
+	var invalid RtfStructure
+	invalid.ParseBytes([]byte("{\\*\\listtable}"))
+	if _, err := invalid.Lists(); !errors.Is(err, ErrNotRTF) {
+		t.Fatalf("expected %v, got %v", ErrNotRTF, err)
+	}
+}
+
+func TestFormatListNumber(t *testing.T) {
+	tests := []struct {
+		format string
+		number int
+		text   string
+	}{
+		{"decimal", 12, "12."},
+		{"upper-roman", 1994, "MCMXCIV."},
+		{"lower-roman", 4, "iv."},
+		{"lower-roman", 4000, "4000."},
+		{"lower-roman", 0, "0."},
+		{"upper-alpha", 1, "A."},
+		{"lower-alpha", 28, "ab."},
+		{"lower-alpha", 702, "zz."},
+		{"lower-alpha", -1, "-1."},
+		{"bullet", 3, "•"},
+		{"none", 3, ""},
+	}
+
+	for _, test := range tests {
+		if text := formatListNumber(RtfListLevel{Format: test.format}, test.number); text != test.text {
+			t.Fatalf("%s %d: got %q, expected %q", test.format, test.number, text, test.text)
+		}
+	}
+}
+
+func TestListNumbering(t *testing.T) {
+	numbering := rtfListNumbering{lists: map[int]RtfList{
+		1: {Levels: []RtfListLevel{{"decimal", 3}, {"lower-alpha", 1}}},
+	}}
+
+	var numbers []int
+	for _, levelIdx := range []int{0, 1, 1, 0, 1, 5} {
+		numbers = append(numbers, numbering.nextNumber(1, levelIdx))
+	}
+	// the numbering of a deeper level restarts after an item of the upper level
+	if !reflect.DeepEqual(numbers, []int{3, 1, 2, 4, 1, 1}) {
+		t.Fatalf("got numbers %v", numbers)
+	}
+	if level := numbering.level(1, 5); level.Format != "bullet" {
+		t.Fatalf("an unknown level is %q", level.Format)
+	}
+
+	// the \pn numbering restarts after a paragraph that is not in a list, the other lists continue
+	numbering.setLevel(rtfParagraphNumberingList, 0, RtfListLevel{"upper-roman", 2})
+	numbering.nextNumber(rtfParagraphNumberingList, 0)
+	numbering.interrupt()
+	if number := numbering.nextNumber(rtfParagraphNumberingList, 0); number != 2 {
+		t.Fatalf("the \\pn numbering continues with %d", number)
+	}
+	if number := numbering.nextNumber(1, 0); number != 5 {
+		t.Fatalf("the list numbering continues with %d", number)
+	}
+}
+
+func TestListConversion(t *testing.T) {
+	tests := []struct {
+		name string
+		rtf  string
+		html string
+		text string
+	}{
+		{
+			"list table",
+			"{\\rtf1" + testListTable + "\\pard\\ls1 one\\par\\ls1 two\\par\\pard\\ls1\\ilvl1 sub\\par\\pard\\ls1 three\\par\\pard after\\par}",
+			"<ol>\r\n<li>one</li>\r\n<li>two<ul>\r\n<li>sub</li>\r\n</ul>\r\n</li>\r\n<li>three</li>\r\n</ol>\r\n<p>after</p>\r\n",
+			"1. one\r\n2. two\r\n    • sub\r\n3. three\r\nafter\r\n",
+		},
+		{
+			"paragraph numbering",
+			"{\\rtf1\\pard{\\pntext 3.}{\\*\\pn\\pnlvlbody\\pndec\\pnstart3}a\\par\\pard{\\*\\pn\\pnlvlbody\\pndec\\pnstart3}b\\par" +
+				"\\pard x\\par\\pard{\\*\\pn\\pnlvlbody\\pnucltr}c\\par\\pard{\\*\\pn\\pnlvlblt}d\\par}",
+			"<ol start=\"3\">\r\n<li>a</li>\r\n<li>b</li>\r\n</ol>\r\n<p>x</p>\r\n<ol type=\"A\">\r\n<li>c</li>\r\n</ol>\r\n<ul>\r\n<li>d</li>\r\n</ul>\r\n",
+			"3. a\r\n4. b\r\nx\r\nA. c\r\n• d\r\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if html := convertRtf(t, []byte(test.rtf), "html"); html != test.html {
+				t.Fatalf("got html %q, expected %q", html, test.html)
+			}
+			if text := convertRtf(t, []byte(test.rtf), "text"); text != test.text {
+				t.Fatalf("got text %q, expected %q", text, test.text)
+			}
+		})
+	}
+}
@@ -0,0 +1,307 @@
+/**
+ * extract the objects embedded in the document ({\object ...} groups): OLE objects and the files attached with the
+ * OLE Package object (the attachments of the Outlook messages)
+ */
+
+package rtfconverter
+
+import (
+	"encoding/binary"
+	"strings"
+)
+
+/**
+ * an object of the document
+ * Width and Height are \objw and \objh (twips), ScaleX and ScaleY are in percents
+ */
+type RtfObject struct {
+	// emb, link, autlink, sub, pub, icemb, html, ocx
+	Type string
+
+	// the class of the object (\*\objclass, eg: Package, Word.Document.8); the OLE class name if \*\objclass is missing
+	Class string
+	Name  string
+
+	Width  int
+	Height int
+	ScaleX int
+	ScaleY int
+
+	// the decoded \*\objdata: an OLE1 object (MS-OLEDS 2.2)
+	Data []byte
+
+	// the native data of an embedded OLE1 object
+	NativeData []byte
+
+	// the file of a Package object: the label, the path of the source file and the file content
+	FileName    string
+	FilePath    string
+	FileContent []byte
+}
+
+var rtfObjectTypes map[string]string = map[string]string{
+	"objemb":     "emb",
+	"objlink":    "link",
+	"objautlink": "autlink",
+	"objsub":     "sub",
+	"objpub":     "pub",
+	"objicemb":   "icemb",
+	"objhtml":    "html",
+	"objocx":     "ocx",
+}
+
+/**
+ * the OLE1 object format id of an embedded object (the linked objects do not have native data)
+ */
+const rtfOle1EmbeddedObject = 2
+
+/**
+ * all the objects of the document, in document order
+ */
+func (rtfObj *RtfStructure) Objects() ([]RtfObject, error) {
+	var objects []RtfObject
+
+	if !rtfObj.IsValid() {
+		return nil, ErrNotRTF
+	}
+
+	encoding := documentEncoding(rtfObj.Root)
+
+	rtfObj.Root.Walk(func(item Element) error {
+		group, ok := item.(*Group)
+		if !ok || !group.IsObject() {
+			return nil
+		}
+
+		objects = append(objects, extractObject(group, encoding))
+		return SkipGroup
+	})
+
+	return objects, nil
+}
+
+/**
+ * check if the group is an object {\object ...}
+ */
+func (r *Group) IsObject() bool {
+	return r.CheckChildAtIndex(0, "object")
+}
+
+/**
+ * {\object <objtype> <objmod>? <objclass>? <objname>? <objtime>? <objsize>? <rsltmod>? <objdata> <result>}
+ * the strings of the OLE1 object are in the document code page
+ */
+func extractObject(item *Group, encoding string) RtfObject {
+	object := RtfObject{ScaleX: 100, ScaleY: 100}
+
+	for _, child := range item.GetChildren() {
+		switch cobj := child.(type) {
+		case *ControlWord:
+			if objectType, ok := rtfObjectTypes[cobj.GetWord()]; ok {
+				object.Type = objectType
+				continue
+			}
+
+			switch cobj.GetWord() {
+			case "objw":
+				object.Width = cobj.GetIntParameter()
+			case "objh":
+				object.Height = cobj.GetIntParameter()
+			case "objscalex":
+				object.ScaleX = cobj.GetIntParameter()
+			case "objscaley":
+				object.ScaleY = cobj.GetIntParameter()
+			}
+		case *Group:
+			word, _ := cobj.GetDestination()
+			if word == nil {
+				continue
+			}
+
+			switch word.GetWord() {
+			case "objclass":
+				object.Class = decodeGroupText(cobj, encoding)
+			case "objname":
+				object.Name = decodeGroupText(cobj, encoding)
+			case "objdata":
+				data := rtfHexDataDecoder{}
+				for _, dataChild := range cobj.GetChildren() {
+					switch dobj := dataChild.(type) {
+					case *Text:
+						data.writeHex(dobj.GetContent())
+					case *Binary:
+						data.writeBinary(dobj.GetData())
+					}
+				}
+				object.Data = data.data
+			}
+		}
+	}
+
+	className, native := parseOle1Object(object.Data)
+	if object.Class == "" && className != nil {
+		object.Class = decodeAnsiString(className, encoding)
+	}
+	object.NativeData = native
+
+	if strings.EqualFold(object.Class, "Package") && native != nil {
+		fileName, filePath, content := parseOlePackage(native)
+		object.FileName = decodeAnsiString(fileName, encoding)
+		object.FilePath = decodeAnsiString(filePath, encoding)
+		object.FileContent = content
+
+		if object.FileName == "" {
+			// the label is missing; the name of the source file is used
+			object.FileName = object.FilePath[strings.LastIndexAny(object.FilePath, "\\/")+1:]
+		}
+	}
+
+	return object
+}
+
+/**
+ * the class name and the native data of an OLE1 object:
+ * OLEVersion (4 bytes), FormatID (4 bytes), ClassName, TopicName, ItemName (length prefixed strings),
+ * NativeDataSize (4 bytes) and NativeData for the embedded objects
+ * nil is returned for the parts that are missing or not valid
+ */
+func parseOle1Object(data []byte) (className []byte, native []byte) {
+	reader := rtfOleReader{data: data}
+
+	reader.uint32()
+	formatId, ok := reader.uint32()
+	if !ok {
+		return nil, nil
+	}
+
+	className, ok = reader.lengthPrefixedString()
+	if !ok || formatId != rtfOle1EmbeddedObject {
+		return className, nil
+	}
+
+	// the topic name and the item name are empty for the embedded objects
+	reader.lengthPrefixedString()
+	if _, ok = reader.lengthPrefixedString(); !ok {
+		return className, nil
+	}
+
+	size, ok := reader.uint32()
+	if !ok {
+		return className, nil
+	}
+
+	native, _ = reader.bytes(int(size))
+	return className, native
+}
+
+/**
+ * the native data of the Package object (the OLE packager):
+ * header (2 bytes), label and source path (null terminated strings), flags (4 bytes),
+ * the temporary path (4 bytes size and null terminated string), the file size (4 bytes) and the file content
+ */
+func parseOlePackage(native []byte) (fileName []byte, filePath []byte, content []byte) {
+	reader := rtfOleReader{data: native}
+
+	reader.uint16()
+	fileName, _ = reader.nullTerminatedString()
+	filePath, _ = reader.nullTerminatedString()
+
+	reader.uint32()
+	tempPathSize, ok := reader.uint32()
+	if !ok {
+		return fileName, filePath, nil
+	}
+	if _, ok = reader.bytes(int(tempPathSize)); !ok {
+		return fileName, filePath, nil
+	}
+
+	size, ok := reader.uint32()
+	if !ok {
+		return fileName, filePath, nil
+	}
+
+	content, _ = reader.bytes(int(size))
+	return fileName, filePath, content
+}
+
+/**
+ * read the little endian values of an OLE structure; a read after the end of the data fails
+ */
+type rtfOleReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *rtfOleReader) bytes(size int) ([]byte, bool) {
+	if size < 0 || size > len(r.data)-r.pos {
+		r.pos = len(r.data)
+		return nil, false
+	}
+
+	b := r.data[r.pos : r.pos+size]
+	r.pos += size
+	return b, true
+}
+
+func (r *rtfOleReader) uint16() (uint16, bool) {
+	b, ok := r.bytes(2)
+	if !ok {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint16(b), true
+}
+
+func (r *rtfOleReader) uint32() (uint32, bool) {
+	b, ok := r.bytes(4)
+	if !ok {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint32(b), true
+}
+
+/**
+ * a string with a 4 bytes length; the length includes the terminating null
+ */
+func (r *rtfOleReader) lengthPrefixedString() ([]byte, bool) {
+	size, ok := r.uint32()
+	if !ok {
+		return nil, false
+	}
+
+	b, ok := r.bytes(int(size))
+	if !ok {
+		return nil, false
+	}
+	return trimNull(b), true
+}
+
+func (r *rtfOleReader) nullTerminatedString() ([]byte, bool) {
+	for i := r.pos; i < len(r.data); i++ {
+		if r.data[i] == 0 {
+			b := r.data[r.pos:i]
+			r.pos = i + 1
+			return b, true
+		}
+	}
+
+	r.pos = len(r.data)
+	return nil, false
+}
+
+func trimNull(b []byte) []byte {
+	for i, c := range b {
+		if c == 0 {
+			return b[:i]
+		}
+	}
+	return b
+}
+
+func decodeAnsiString(b []byte, encoding string) string {
+	if len(b) == 0 {
+		return ""
+	}
+
+	t, _ := ConvertToUtf8(b, encoding)
+	return string(t)
+}
@@ -0,0 +1,152 @@
+package rtfconverter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+/**
+ * an OLE1 object: the version, the format id, the class name, empty topic and item names and the native data
+ */
+func testOle1Object(formatId uint32, className string, native []byte) []byte {
+	data := &bytes.Buffer{}
+	binary.Write(data, binary.LittleEndian, uint32(0x0501))
+	binary.Write(data, binary.LittleEndian, formatId)
+	binary.Write(data, binary.LittleEndian, uint32(len(className)+1))
+	data.WriteString(className + "\x00")
+	binary.Write(data, binary.LittleEndian, uint32(0))
+	binary.Write(data, binary.LittleEndian, uint32(0))
+	if native != nil {
+		binary.Write(data, binary.LittleEndian, uint32(len(native)))
+		data.Write(native)
+	}
+	return data.Bytes()
+}
+
+/**
+ * the native data of a Package object
+ */
+func testOlePackage(label string, path string, content []byte) []byte {
+	data := &bytes.Buffer{}
+	binary.Write(data, binary.LittleEndian, uint16(2))
+	data.WriteString(label + "\x00" + path + "\x00")
+	binary.Write(data, binary.LittleEndian, uint32(0x00030000))
+	binary.Write(data, binary.LittleEndian, uint32(len(path)+1))
+	data.WriteString(path + "\x00")
+	binary.Write(data, binary.LittleEndian, uint32(len(content)))
+	data.Write(content)
+	return data.Bytes()
+}
+
+func testObjectGroup(words string, class string, data []byte) string {
+	group := "{\\object" + words
+	if class != "" {
+		group += "{\\*\\objclass " + class + "}"
+	}
+	return group + "{\\*\\objdata\r\n" + hex.EncodeToString(data) + "\r\n}{\\result{\\pict\\wmetafile8 0100}}}"
+}
+
+func TestObjects(t *testing.T) {
+	content := []byte("file content")
+	packageData := testOle1Object(2, "Package", testOlePackage("r\xe9sum\xe9.txt", "C:\\tmp\\resume.txt", content))
+	noLabelData := testOle1Object(2, "Package", testOlePackage("", "C:\\tmp\\other.txt", content))
+	wordData := testOle1Object(2, "Word.Document.8", []byte{1, 2, 3})
+	linkData := testOle1Object(1, "Excel.Sheet.8", nil)
+
+	rtf := "{\\rtf1\\ansi\\ansicpg1252 a" +
+		testObjectGroup("\\objemb\\objw1000\\objh500\\objscalex50", "Package", packageData) +
+		testObjectGroup("\\objicemb", "", noLabelData) +
+		"{\\shp{\\shpinst " + testObjectGroup("\\objemb", "", wordData) + "}}" +
+		testObjectGroup("\\objlink", "", linkData) +
+		"b\\par}"
+
+	var rtfObj RtfStructure
+	if err := rtfObj.ParseBytes([]byte(rtf)); err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	objects, err := rtfObj.Objects()
+	if err != nil {
+		t.Fatalf("objects failed: %v", err)
+	}
+	if len(objects) != 4 {
+		t.Fatalf("got %d objects, expected 4", len(objects))
+	}
+
+	pkg := objects[0]
+	if pkg.Type != "emb" || pkg.Class != "Package" || pkg.Width != 1000 || pkg.Height != 500 || pkg.ScaleX != 50 || pkg.ScaleY != 100 {
+		t.Fatalf("wrong package object: %+v", pkg)
+	}
+	if !bytes.Equal(pkg.Data, packageData) {
+		t.Fatalf("wrong object data")
+	}
+	if pkg.FileName != "résumé.txt" || pkg.FilePath != "C:\\tmp\\resume.txt" || !bytes.Equal(pkg.FileContent, content) {
+		t.Fatalf("wrong package file: %q %q %q", pkg.FileName, pkg.FilePath, pkg.FileContent)
+	}
+
+	// the class is read from the OLE object and the name from the source path
+	if obj := objects[1]; obj.Type != "icemb" || obj.Class != "Package" || obj.FileName != "other.txt" || !bytes.Equal(obj.FileContent, content) {
+		t.Fatalf("wrong package object without label: %+v", obj)
+	}
+
+	if obj := objects[2]; obj.Class != "Word.Document.8" || !bytes.Equal(obj.NativeData, []byte{1, 2, 3}) || obj.FileName != "" || obj.FileContent != nil {
+		t.Fatalf("wrong word object: %+v", obj)
+	}
+
+	// a linked object has no native data
+	if obj := objects[3]; obj.Type != "link" || obj.Class != "Excel.Sheet.8" || obj.NativeData != nil {
+		t.Fatalf("wrong linked object: %+v", obj)
+	}
+}
+
+func TestObjectsInvalidData(t *testing.T) {
+	packageData := testOle1Object(2, "Package", testOlePackage("a.txt", "C:\\a.txt", []byte("content")))
+
+	tests := []struct {
+		name    string
+		data    []byte
+		class   string
+		native  bool
+		content bool
+	}{
+		{"empty", nil, "", false, false},
+		{"truncated header", packageData[:6], "", false, false},
+		{"truncated class name", packageData[:14], "", false, false},
+		{"truncated native data", packageData[:len(packageData)-1], "Package", false, false},
+		{"oversized native size", append(packageData[:28:28], 0xff, 0xff, 0xff, 0x7f), "Package", false, false},
+		{"valid", packageData, "Package", true, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var rtfObj RtfStructure
+			if err := rtfObj.ParseBytes([]byte("{\\rtf1" + testObjectGroup("\\objemb", "", test.data) + "}")); err != nil {
+				t.Fatalf("parse failed: %v", err)
+			}
+			objects, err := rtfObj.Objects()
+			if err != nil || len(objects) != 1 {
+				t.Fatalf("got %d objects, %v", len(objects), err)
+			}
+
+			obj := objects[0]
+			if obj.Class != test.class || (obj.NativeData != nil) != test.native || (obj.FileContent != nil) != test.content {
+				t.Fatalf("got class %q, %d native bytes, %d file bytes", obj.Class, len(obj.NativeData), len(obj.FileContent))
+			}
+		})
+	}
+
+	// the file content of a Package is truncated, the native data is complete
+	native := testOlePackage("a.txt", "C:\\a.txt", []byte("content"))
+	fileName, filePath, content := parseOlePackage(native[:len(native)-1])
+	if string(fileName) != "a.txt" || string(filePath) != "C:\\a.txt" || content != nil {
+		t.Fatalf("got %q %q %q", fileName, filePath, content)
+	}
+
+	var rtfObj RtfStructure
+	rtfObj.ParseBytes([]byte("{\\object\\objemb}"))
+	if _, err := rtfObj.Objects(); !errors.Is(err, ErrNotRTF) {
+		t.Fatalf("expected %v, got %v", ErrNotRTF, err)
+	}
+}
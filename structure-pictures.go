@@ -0,0 +1,268 @@
+/**
+ * extract the pictures embedded in the document ({\pict ...} groups)
+ */
+
+package rtfconverter
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+)
+
+/**
+ * a picture of the document
+ * Width and Height are \picw and \pich (pixels for bitmaps, the metafile units for metafiles), WidthGoal and HeightGoal
+ * are the desired size in twips, ScaleX and ScaleY are in percents
+ */
+type RtfPicture struct {
+	// png, jpeg, emf, wmf, dib, bmp, macpict, pmmetafile
+	Format      string
+	ContentType string
+
+	Width      int
+	Height     int
+	WidthGoal  int
+	HeightGoal int
+	ScaleX     int
+	ScaleY     int
+
+	// the decoded hex or \bin data
+	Data []byte
+}
+
+/**
+ * return the url (or cid) of the picture used by the html converters as the src of <img>; an error stops the conversion
+ */
+type RtfPictureHandler func(picture RtfPicture) (string, error)
+
+var rtfPictureFormats map[string][2]string = map[string][2]string{
+	"pngblip":    {"png", "image/png"},
+	"jpegblip":   {"jpeg", "image/jpeg"},
+	"emfblip":    {"emf", "image/emf"},
+	"wmetafile":  {"wmf", "image/wmf"},
+	"dibitmap":   {"dib", "image/bmp"},
+	"wbitmap":    {"bmp", "application/octet-stream"},
+	"macpict":    {"macpict", "image/pict"},
+	"pmmetafile": {"pmmetafile", "application/octet-stream"},
+}
+
+/**
+ * all the pictures of the document, in document order
+ * the pictures of \nonshppict groups are skipped: they are copies of the \shppict pictures for the old readers
+ */
+func (rtfObj *RtfStructure) Pictures() ([]RtfPicture, error) {
+	var pictures []RtfPicture
+
+	if !rtfObj.IsValid() {
+		return nil, ErrNotRTF
+	}
+
+	rtfObj.Root.Walk(func(item Element) error {
+		group, ok := item.(*Group)
+		if !ok {
+			return nil
+		}
+
+		if group.IsPicture() {
+			pictures = append(pictures, extractPicture(group))
+			return SkipGroup
+		} else if group.CheckChildAtIndex(0, "nonshppict") {
+			return SkipGroup
+		}
+		return nil
+	})
+
+	return pictures, nil
+}
+
+/**
+ * check if the group is a picture {\pict ...}
+ */
+func (r *Group) IsPicture() bool {
+	return r.CheckChildAtIndex(0, "pict")
+}
+
+/**
+ * {\pict <brdr>? <shading>? <picttype> <pictsize> <metafileinfo>? <data>}
+ * the data is hex text or \bin data; the nested groups (\*\blipuid, \*\picprop, etc) are not part of the data
+ */
+func extractPicture(item *Group) RtfPicture {
+	builder := newRtfPictureBuilder()
+
+	for _, child := range item.GetChildren() {
+		builder.parseElement(child)
+	}
+
+	return builder.result()
+}
+
+/**
+ * build a picture from the elements of the {\pict} group, one by one; the hex data is decoded while it is received,
+ * so in stream mode the hex text is not kept
+ */
+type rtfPictureBuilder struct {
+	picture RtfPicture
+	data    rtfHexDataDecoder
+}
+
+func newRtfPictureBuilder() *rtfPictureBuilder {
+	return &rtfPictureBuilder{picture: RtfPicture{ScaleX: 100, ScaleY: 100}}
+}
+
+/**
+ * parse an element of the picture group; the nested groups are ignored
+ */
+func (b *rtfPictureBuilder) parseElement(item Element) {
+	switch cobj := item.(type) {
+	case *ControlWord:
+		if format, ok := rtfPictureFormats[cobj.GetWord()]; ok {
+			b.picture.Format = format[0]
+			b.picture.ContentType = format[1]
+			return
+		}
+
+		switch cobj.GetWord() {
+		case "picw":
+			b.picture.Width = cobj.GetIntParameter()
+		case "pich":
+			b.picture.Height = cobj.GetIntParameter()
+		case "picwgoal":
+			b.picture.WidthGoal = cobj.GetIntParameter()
+		case "pichgoal":
+			b.picture.HeightGoal = cobj.GetIntParameter()
+		case "picscalex":
+			b.picture.ScaleX = cobj.GetIntParameter()
+		case "picscaley":
+			b.picture.ScaleY = cobj.GetIntParameter()
+		}
+	case *Text:
+		b.data.writeHex(cobj.GetContent())
+	case *Binary:
+		b.data.writeBinary(cobj.GetData())
+	}
+}
+
+func (b *rtfPictureBuilder) result() RtfPicture {
+	picture := b.picture
+	picture.Data = b.data.data
+	return picture
+}
+
+/**
+ * decode the data of a destination written as hex digits, split in several texts, or as \bin data
+ */
+type rtfHexDataDecoder struct {
+	data []byte
+
+	// the first digit of an incomplete byte
+	nibble   byte
+	halfByte bool
+}
+
+func (d *rtfHexDataDecoder) writeHex(text []byte) {
+	for _, b := range text {
+		v, ok := hexDigitValue(b)
+		if !ok {
+			// whitespaces between the hex digits
+			continue
+		}
+
+		if d.halfByte {
+			d.data = append(d.data, d.nibble<<4|v)
+		} else {
+			d.nibble = v
+		}
+		d.halfByte = !d.halfByte
+	}
+}
+
+func (d *rtfHexDataDecoder) writeBinary(data []byte) {
+	d.data = append(d.data, data...)
+}
+
+func hexDigitValue(b byte) (byte, bool) {
+	switch {
+	case b >= '0' && b <= '9':
+		return b - '0', true
+	case b >= 'a' && b <= 'f':
+		return b - 'a' + 10, true
+	case b >= 'A' && b <= 'F':
+		return b - 'A' + 10, true
+	}
+	return 0, false
+}
+
+/**
+ * the display size of the picture in pixels (96 dpi, 15 twips per pixel); 0 if the size is unknown
+ */
+func (pic RtfPicture) DisplaySize() (width int, height int) {
+	width, height = pic.WidthGoal/15, pic.HeightGoal/15
+
+	if width == 0 || height == 0 {
+		switch pic.Format {
+		case "png", "jpeg", "dib", "bmp":
+			// the size of the bitmaps is in pixels
+			width, height = pic.Width, pic.Height
+		default:
+			return 0, 0
+		}
+	}
+
+	return width * pic.ScaleX / 100, height * pic.ScaleY / 100
+}
+
+/**
+ * the picture as a data: uri; a device independent bitmap is converted to a bmp file
+ */
+func (pic RtfPicture) DataURI() string {
+	data := pic.Data
+	if pic.Format == "dib" {
+		data = dibToBmp(data)
+	}
+
+	contentType := pic.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+/**
+ * add the bitmap file header to a device independent bitmap (BITMAPINFOHEADER + color table + pixels)
+ */
+func dibToBmp(dib []byte) []byte {
+	if len(dib) < 40 {
+		return dib
+	}
+
+	headerSize := binary.LittleEndian.Uint32(dib[0:4])
+	bitCount := binary.LittleEndian.Uint16(dib[14:16])
+	compression := binary.LittleEndian.Uint32(dib[16:20])
+	colorsUsed := binary.LittleEndian.Uint32(dib[32:36])
+
+	if headerSize < 40 || uint64(headerSize) > uint64(len(dib)) {
+		return dib
+	}
+
+	paletteSize := uint64(colorsUsed) * 4
+	if colorsUsed == 0 && bitCount <= 8 {
+		paletteSize = (uint64(1) << bitCount) * 4
+	}
+	if headerSize == 40 && compression == 3 {
+		// BI_BITFIELDS: the color masks follow the header
+		paletteSize += 12
+	}
+
+	pixelsOffset := 14 + uint64(headerSize) + paletteSize
+	if pixelsOffset > uint64(len(dib))+14 {
+		return dib
+	}
+
+	bmp := make([]byte, 14, 14+len(dib))
+	bmp[0], bmp[1] = 'B', 'M'
+	binary.LittleEndian.PutUint32(bmp[2:6], uint32(14+len(dib)))
+	binary.LittleEndian.PutUint32(bmp[10:14], uint32(pixelsOffset))
+
+	return append(bmp, dib...)
+}
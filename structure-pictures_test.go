@@ -0,0 +1,119 @@
+package rtfconverter
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPictures(t *testing.T) {
+	rtf := "{\\rtf1 a" +
+		"{\\pict{\\*\\picprop{\\sp{\\sn x}{\\sv 1}}}\\pngblip\\picw10\\pich5\\picwgoal300\\pichgoal150\\picscalex50{\\*\\blipuid ff}\n8950\n4E 4\r\n7}" +
+		"{\\*\\shppict{\\pict\\jpegblip\\bin3 \xff\xd8\xff}}{\\nonshppict{\\pict\\wmetafile8 0100}}" +
+		"{\\pict\\emfblip 0}" +
+		"b\\par}"
+
+	var rtfObj RtfStructure
+	if err := rtfObj.ParseBytes([]byte(rtf)); err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	pictures, err := rtfObj.Pictures()
+	if err != nil {
+		t.Fatalf("pictures failed: %v", err)
+	}
+
+	expected := []RtfPicture{
+		{Format: "png", ContentType: "image/png", Width: 10, Height: 5, WidthGoal: 300, HeightGoal: 150, ScaleX: 50, ScaleY: 100, Data: []byte{0x89, 0x50, 0x4e, 0x47}},
+		{Format: "jpeg", ContentType: "image/jpeg", ScaleX: 100, ScaleY: 100, Data: []byte{0xff, 0xd8, 0xff}},
+		// an incomplete hex byte is dropped
+		{Format: "emf", ContentType: "image/emf", ScaleX: 100, ScaleY: 100},
+	}
+	if !reflect.DeepEqual(pictures, expected) {
+		t.Fatalf("got %+v\nexpected %+v", pictures, expected)
+	}
+
+	if width, height := pictures[0].DisplaySize(); width != 10 || height != 10 {
+		t.Fatalf("display size %dx%d, expected 10x10", width, height)
+	}
+	if uri := pictures[0].DataURI(); uri != "data:image/png;base64,iVBORw==" {
+		t.Fatalf("got data uri %q", uri)
+	}
+}
+
+func TestPictureDisplaySize(t *testing.T) {
+	tests := []struct {
+		picture RtfPicture
+		width   int
+		height  int
+	}{
+		{RtfPicture{Format: "png", Width: 40, Height: 20, ScaleX: 100, ScaleY: 100}, 40, 20},
+		{RtfPicture{Format: "jpeg", Width: 40, Height: 20, WidthGoal: 1500, ScaleX: 100, ScaleY: 50}, 40, 10},
+		{RtfPicture{Format: "wmf", Width: 4000, Height: 2000, ScaleX: 100, ScaleY: 100}, 0, 0},
+		{RtfPicture{Format: "emf", WidthGoal: 1500, HeightGoal: 750, ScaleX: 200, ScaleY: 200}, 200, 100},
+	}
+
+	for i, test := range tests {
+		if width, height := test.picture.DisplaySize(); width != test.width || height != test.height {
+			t.Fatalf("picture %d: display size %dx%d, expected %dx%d", i, width, height, test.width, test.height)
+		}
+	}
+}
+
+func TestPictureDibToBmp(t *testing.T) {
+	// BITMAPINFOHEADER of a 1x1 8-bit bitmap with 2 colors, the palette and the pixels
+	dib := make([]byte, 40+8+4)
+	dib[0] = 40
+	dib[14] = 8
+	dib[32] = 2
+
+	bmp := dibToBmp(dib)
+	if string(bmp[:2]) != "BM" || len(bmp) != 14+len(dib) || int(getU32(bmp, 2)) != len(bmp) || getU32(bmp, 10) != 14+40+8 {
+		t.Fatalf("wrong bitmap file header: % x", bmp[:14])
+	}
+
+	uri := RtfPicture{Format: "dib", ContentType: "image/bmp", Data: dib}.DataURI()
+	if uri != "data:image/bmp;base64,"+base64.StdEncoding.EncodeToString(bmp) {
+		t.Fatalf("got data uri %q", uri)
+	}
+
+	// a header that is not valid is not changed
+	for _, data := range [][]byte{dib[:39], append([]byte{0xff}, dib[1:]...)} {
+		if !bytes.Equal(dibToBmp(data), data) {
+			t.Fatalf("an invalid dib was changed")
+		}
+	}
+
+	// without content type
+	if uri := (RtfPicture{Data: []byte{1}}).DataURI(); uri != "data:application/octet-stream;base64,AQ==" {
+		t.Fatalf("got data uri %q", uri)
+	}
+}
+
+func TestPicturesErrors(t *testing.T) {
+	var rtfObj RtfStructure
+	rtfObj.ParseBytes([]byte("{\\pict\\pngblip 00}"))
+	if _, err := rtfObj.Pictures(); !errors.Is(err, ErrNotRTF) {
+		t.Fatalf("expected %v, got %v", ErrNotRTF, err)
+	}
+
+	if err := rtfObj.ParseBytes([]byte("{\\rtf1 text}")); err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if pictures, err := rtfObj.Pictures(); err != nil || len(pictures) != 0 {
+		t.Fatalf("got %d pictures, %v", len(pictures), err)
+	}
+
+	// the error of the picture handler stops the conversion
+	handlerErr := errors.New("no storage")
+	c := NewConverter(WithPictureHandler(func(picture RtfPicture) (string, error) {
+		return "", handlerErr
+	}))
+	if err := c.SetBytes([]byte("{\\rtf1 a{\\pict\\pngblip 00}b}")); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if _, err := c.Convert("html"); !errors.Is(err, handlerErr) {
+		t.Fatalf("expected %v, got %v", handlerErr, err)
+	}
+}
@@ -0,0 +1,248 @@
+/**
+ * find groups and control words of the tree with a selector
+ *
+ * a selector is a list of steps separated by a space (descendant) or > (child):
+ *	name		a group whose destination is name, marked or not with \* (eg: pict, info, fonttbl)
+ *	\*\name		a group whose destination is name, marked with \* (eg: \*\htmltag)
+ *	\name		a control word (eg: \pngblip)
+ * a step may have a numeric parameter (eg: \*\htmltag50, \fs24); without parameter any parameter matches
+ *
+ * eg: Find("\\*\\htmltag"), FindAll("pict > \\pngblip"), FindAll("fonttbl \\f")
+ */
+
+package rtfconverter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// stops the walk when the first element is found
+var errSelectorFound = errors.New("the element was found")
+
+type rtfSelectorStep struct {
+	// the element must be a direct child of the element matched by the previous step
+	child bool
+
+	// the step matches a control word, or a group with the destination word
+	isWord bool
+	word   string
+
+	// the group must be marked with \*
+	optional bool
+
+	parameter    string
+	hasParameter bool
+}
+
+/**
+ * the first element that matches the selector; nil if there is no match
+ */
+func (rtfObj *RtfStructure) Find(selector string) (Element, error) {
+	if rtfObj.Root == nil {
+		return nil, nil
+	}
+	return rtfObj.Root.Find(selector)
+}
+
+/**
+ * all the elements that match the selector, in document order
+ */
+func (rtfObj *RtfStructure) FindAll(selector string) ([]Element, error) {
+	if rtfObj.Root == nil {
+		return nil, nil
+	}
+	return rtfObj.Root.FindAll(selector)
+}
+
+/**
+ * the first destination group of the path; the destinations are separated by / and each one is a child of the previous one
+ * eg: FindDestination("info/author") - the author group of the document info
+ */
+func (rtfObj *RtfStructure) FindDestination(path string) *Group {
+	if rtfObj.Root == nil {
+		return nil
+	}
+	return rtfObj.Root.FindDestination(path)
+}
+
+func (r *Group) Find(selector string) (Element, error) {
+	var found Element
+
+	steps, err := parseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Walk(func(item Element) error {
+		if matchSelector(item, steps) {
+			found = item
+			return errSelectorFound
+		}
+		return nil
+	})
+	return found, nil
+}
+
+func (r *Group) FindAll(selector string) ([]Element, error) {
+	var found []Element
+
+	steps, err := parseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Walk(func(item Element) error {
+		if matchSelector(item, steps) {
+			found = append(found, item)
+		}
+		return nil
+	})
+	return found, nil
+}
+
+func (r *Group) FindDestination(path string) *Group {
+	names := strings.Split(strings.Trim(path, "/"), "/")
+	for _, name := range names {
+		if name == "" || strings.ContainsAny(name, "\\> ") {
+			return nil
+		}
+	}
+
+	item, err := r.Find(strings.Join(names, " > "))
+	if err != nil || item == nil {
+		return nil
+	}
+	return item.(*Group)
+}
+
+/**
+ * the destination word of the group: the first control word, after the optional \*
+ */
+func (r *Group) GetDestination() (word *ControlWord, optional bool) {
+	idx := 0
+	if r.IsDestination() {
+		optional = true
+		idx = 1
+	}
+
+	if idx < len(r.children) {
+		word, _ = r.children[idx].(*ControlWord)
+	}
+	return word, optional
+}
+
+func parseSelector(selector string) ([]rtfSelectorStep, error) {
+	var steps []rtfSelectorStep
+
+	fields := strings.Fields(strings.ReplaceAll(selector, ">", " > "))
+	child := false
+	for _, field := range fields {
+		if field == ">" {
+			if len(steps) == 0 || child {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidSelector, selector)
+			}
+			child = true
+			continue
+		}
+
+		step, ok := parseSelectorStep(field)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSelector, selector)
+		}
+		step.child = child
+		child = false
+		steps = append(steps, step)
+	}
+
+	if len(steps) == 0 || child {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidSelector, selector)
+	}
+	return steps, nil
+}
+
+/**
+ * name, \*\name or \name followed by an optional parameter
+ */
+func parseSelectorStep(field string) (rtfSelectorStep, bool) {
+	step := rtfSelectorStep{}
+
+	switch {
+	case strings.HasPrefix(field, "\\*\\"):
+		step.optional = true
+		field = field[3:]
+	case strings.HasPrefix(field, "\\"):
+		step.isWord = true
+		field = field[1:]
+	}
+
+	i := 0
+	for i < len(field) && ByteIsAsciiLetter(field[i]) {
+		i++
+	}
+	if i == 0 {
+		return step, false
+	}
+	step.word = field[:i]
+
+	if parameter := field[i:]; parameter != "" {
+		digits := strings.TrimPrefix(parameter, "-")
+		if digits == "" {
+			return step, false
+		}
+		for j := 0; j < len(digits); j++ {
+			if !ByteIsDigit(digits[j]) {
+				return step, false
+			}
+		}
+		step.parameter = parameter
+		step.hasParameter = true
+	}
+
+	return step, true
+}
+
+/**
+ * the selector matches the item if the last step matches the item and the previous steps match its ancestors
+ */
+func matchSelector(item Element, steps []rtfSelectorStep) bool {
+	last := steps[len(steps)-1]
+	if !last.matches(item) {
+		return false
+	}
+	if len(steps) == 1 {
+		return true
+	}
+
+	parent := item.GetParent()
+	if last.child {
+		return parent != nil && matchSelector(parent, steps[:len(steps)-1])
+	}
+
+	for ; parent != nil; parent = parent.GetParent() {
+		if matchSelector(parent, steps[:len(steps)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s rtfSelectorStep) matches(item Element) bool {
+	var word *ControlWord
+
+	if s.isWord {
+		word, _ = item.(*ControlWord)
+	} else if group, ok := item.(*Group); ok {
+		var optional bool
+		word, optional = group.GetDestination()
+		if s.optional && !optional {
+			return false
+		}
+	}
+
+	if word == nil || word.GetWord() != s.word {
+		return false
+	}
+	return !s.hasParameter || word.GetParameter() == s.parameter
+}
@@ -0,0 +1,132 @@
+package rtfconverter
+
+import (
+	"errors"
+	"testing"
+)
+
+const testQueryRtf = "{\\rtf1{\\fonttbl{\\f0 Arial;}{\\f1 Times;}}{\\info{\\author me}{\\title doc}}" +
+	"{\\*\\htmltag50 <br>}{\\htmltag <p>}\\fs24 a{\\pict\\pngblip 00}{\\shp{\\pict\\fs20\\jpegblip 00}}}"
+
+/**
+ * a short description of the element: the destination word of a group or the control word
+ */
+func queryElementName(item Element) string {
+	switch obj := item.(type) {
+	case *Group:
+		word, optional := obj.GetDestination()
+		if word == nil {
+			return "{}"
+		}
+		if optional {
+			return "{\\*\\" + word.GetWord() + word.GetParameter() + "}"
+		}
+		return "{\\" + word.GetWord() + word.GetParameter() + "}"
+	case *ControlWord:
+		return "\\" + obj.GetWord() + obj.GetParameter()
+	}
+	return ""
+}
+
+func TestFindAll(t *testing.T) {
+	var rtfObj RtfStructure
+	if err := rtfObj.ParseBytes([]byte(testQueryRtf)); err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	tests := []struct {
+		selector string
+		found    []string
+	}{
+		{"fonttbl \\f", []string{"\\f0", "\\f1"}},
+		{"fonttbl > \\f", nil},
+		{"\\f1", []string{"\\f1"}},
+		{"info>author", []string{"{\\author}"}},
+		{"htmltag", []string{"{\\*\\htmltag50}", "{\\htmltag}"}},
+		{"\\*\\htmltag", []string{"{\\*\\htmltag50}"}},
+		{"htmltag50", []string{"{\\*\\htmltag50}"}},
+		{"htmltag5", nil},
+		{"\\fs", []string{"\\fs24", "\\fs20"}},
+		{"pict > \\fs", []string{"\\fs20"}},
+		{"\\fs-2", nil},
+		{"shp pict", []string{"{\\pict}"}},
+		{"rtf > pict \\pngblip", []string{"\\pngblip"}},
+		{"pict", []string{"{\\pict}", "{\\pict}"}},
+		{"nothing", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.selector, func(t *testing.T) {
+			items, err := rtfObj.FindAll(test.selector)
+			if err != nil {
+				t.Fatalf("FindAll failed: %v", err)
+			}
+
+			var found []string
+			for _, item := range items {
+				found = append(found, queryElementName(item))
+			}
+			if len(found) != len(test.found) {
+				t.Fatalf("found %q, expected %q", found, test.found)
+			}
+			for i := range found {
+				if found[i] != test.found[i] {
+					t.Fatalf("found %q, expected %q", found, test.found)
+				}
+			}
+
+			item, err := rtfObj.Find(test.selector)
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+			if len(items) == 0 && item != nil || len(items) > 0 && item != items[0] {
+				t.Fatalf("Find returned %s, expected the first element of FindAll", queryElementName(item))
+			}
+		})
+	}
+}
+
+func TestFindInvalidSelector(t *testing.T) {
+	var rtfObj RtfStructure
+	if err := rtfObj.ParseBytes([]byte(testQueryRtf)); err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	for _, selector := range []string{"", "  ", "> pict", "pict >", "info > > author", "\\", "\\*\\", "\\24", "fs2a", "\\fs-", "pi-ct"} {
+		if _, err := rtfObj.Find(selector); !errors.Is(err, ErrInvalidSelector) {
+			t.Fatalf("Find(%q): expected %v, got %v", selector, ErrInvalidSelector, err)
+		}
+		if _, err := rtfObj.FindAll(selector); !errors.Is(err, ErrInvalidSelector) {
+			t.Fatalf("FindAll(%q): expected %v, got %v", selector, ErrInvalidSelector, err)
+		}
+	}
+
+	// a document without tree has no elements
+	var empty RtfStructure
+	if item, err := empty.Find("pict"); item != nil || err != nil {
+		t.Fatalf("Find on an empty document returned %v, %v", item, err)
+	}
+}
+
+func TestFindDestination(t *testing.T) {
+	var rtfObj RtfStructure
+	if err := rtfObj.ParseBytes([]byte(testQueryRtf)); err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	if group := rtfObj.FindDestination("/info/title/"); group == nil || string(group.GetText()) != "doc" {
+		t.Fatalf("the title group is not found")
+	}
+	if group := rtfObj.FindDestination("title"); group == nil || group.GetParent() != rtfObj.FindDestination("info") {
+		t.Fatalf("the title group is not found without its parent")
+	}
+	if group := rtfObj.FindDestination("shp/pict"); group == nil || group.GetParent().GetParent() != rtfObj.Root {
+		t.Fatalf("the picture of the shape is not found")
+	}
+
+	for _, path := range []string{"rtf/title", "info//title", "info/\\title", "info > title", ""} {
+		if group := rtfObj.FindDestination(path); group != nil {
+			t.Fatalf("FindDestination(%q) found a group", path)
+		}
+	}
+}
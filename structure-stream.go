@@ -0,0 +1,313 @@
+/**
+ * feed the interpreters with the tokens of a RTF document, without building the tree of the entire document
+ */
+
+package rtfconverter
+
+import (
+	"io"
+)
+
+/**
+ * what an interpreter does with a group
+ */
+type rtfGroupAction int
+
+const (
+	// parse the elements of the group one by one
+	rtfGroupWalk rtfGroupAction = iota
+
+	// ignore the group and all its elements
+	rtfGroupSkip
+
+	// build the tree of the group and parse it when the group ends (eg: fonttbl, colortbl)
+	rtfGroupCollect
+)
+
+/**
+ * an interpreter that can parse the document both from the tree and from the token stream
+ *
+ * the groups received by groupAction, startGroup and endGroup are complete in tree mode; in stream mode only the
+ * first 2 elements of the group are available, enough for the group checks (IsDestination, IsFontTable, CheckChildAtIndex, etc)
+ */
+type rtfStreamVisitor interface {
+	// the converted document is written to w; endDocument flushes the output
+	startDocument(w io.Writer)
+	endDocument() error
+
+	groupAction(item *Group) rtfGroupAction
+	startGroup(item *Group)
+	endGroup(item *Group)
+	parseCollectedGroup(item *Group)
+
+	// parse a control word, a control symbol or a text
+	parseElement(item Element)
+}
+
+/**
+ * parse the entire tree of the document
+ */
+func parseTree(v rtfStreamVisitor, rtfObj RtfStructure, w io.Writer) error {
+	if !rtfObj.IsValid() {
+		return ErrNotRTF
+	}
+
+	v.startDocument(w)
+	walkGroup(v, rtfObj.Root)
+
+	return v.endDocument()
+}
+
+/**
+ * parse a group from the tree
+ */
+func walkGroup(v rtfStreamVisitor, item *Group) {
+	switch v.groupAction(item) {
+	case rtfGroupSkip:
+		// ignore the group
+	case rtfGroupCollect:
+		v.parseCollectedGroup(item)
+	default:
+		v.startGroup(item)
+		for _, child := range item.GetChildren() {
+			v.parseElement(child)
+		}
+		v.endGroup(item)
+	}
+}
+
+/**
+ * maximum number of elements buffered at the beginning of a group before the interpreter decides what to do with the group
+ */
+const rtfStreamGroupHeadSize = 2
+
+type rtfStreamWalker struct {
+	visitor rtfStreamVisitor
+
+	// the walked groups; only the first elements of each group are kept
+	groups []*Group
+
+	// the group that just started and its first elements
+	head *Group
+
+	// how many groups are opened inside a skipped group
+	skipDepth int
+
+	// the collected group, and the current group inside the collected one
+	collected        *Group
+	collectedCurrent *Group
+}
+
+func newRtfStreamWalker(v rtfStreamVisitor) *rtfStreamWalker {
+	return &rtfStreamWalker{visitor: v}
+}
+
+func (w *rtfStreamWalker) handleToken(token RtfToken) {
+	if w.head != nil {
+		if token.Type != RtfTokenGroupStart && token.Type != RtfTokenGroupEnd {
+			w.head.addChild(tokenElement(token))
+			if len(w.head.children) >= rtfStreamGroupHeadSize {
+				w.resolveHead()
+			}
+			return
+		}
+
+		// the group head ends when a nested group starts or when the group ends
+		w.resolveHead()
+	}
+
+	switch {
+	case w.skipDepth > 0:
+		w.skipToken(token)
+	case w.collected != nil:
+		w.collectToken(token)
+	default:
+		w.walkToken(token)
+	}
+}
+
+func (w *rtfStreamWalker) skipToken(token RtfToken) {
+	switch token.Type {
+	case RtfTokenGroupStart:
+		w.skipDepth++
+	case RtfTokenGroupEnd:
+		w.skipDepth--
+	}
+}
+
+func (w *rtfStreamWalker) collectToken(token RtfToken) {
+	switch token.Type {
+	case RtfTokenGroupStart:
+		group := &Group{}
+		w.collectedCurrent.addChild(group)
+		w.collectedCurrent = group
+	case RtfTokenGroupEnd:
+		if w.collectedCurrent != w.collected {
+			w.collectedCurrent = w.collectedCurrent.GetParent()
+			return
+		}
+
+		// the collected group is complete
+		group := w.collected
+		w.collected = nil
+		w.collectedCurrent = nil
+		w.visitor.parseCollectedGroup(group)
+	default:
+		w.collectedCurrent.addChild(tokenElement(token))
+	}
+}
+
+func (w *rtfStreamWalker) walkToken(token RtfToken) {
+	switch token.Type {
+	case RtfTokenGroupStart:
+		w.head = &Group{}
+	case RtfTokenGroupEnd:
+		if len(w.groups) > 0 {
+			group := w.groups[len(w.groups)-1]
+			w.groups = w.groups[:len(w.groups)-1]
+			w.visitor.endGroup(group)
+		}
+	default:
+		w.visitor.parseElement(tokenElement(token))
+	}
+}
+
+/**
+ * the document ended; close the group head if the last group was not closed
+ */
+func (w *rtfStreamWalker) finish() {
+	w.resolveHead()
+	for len(w.groups) > 0 && w.skipDepth == 0 && w.collected == nil {
+		w.walkToken(RtfToken{Type: RtfTokenGroupEnd})
+	}
+}
+
+/**
+ * the first elements of the new group are known; ask the interpreter what to do with the group
+ */
+func (w *rtfStreamWalker) resolveHead() {
+	if w.head == nil {
+		return
+	}
+
+	head := w.head
+	w.head = nil
+
+	switch w.visitor.groupAction(head) {
+	case rtfGroupSkip:
+		w.skipDepth = 1
+	case rtfGroupCollect:
+		w.collected = head
+		w.collectedCurrent = head
+	default:
+		w.visitor.startGroup(head)
+		w.groups = append(w.groups, head)
+		for _, child := range head.GetChildren() {
+			w.visitor.parseElement(child)
+		}
+	}
+}
+
+/**
+ * the de-encapsulating RTF reader inspects no more than the first 10 RTF tokens (begin group marks and control words)
+ */
+const rtfEncapsulationInspectedTokens = 10
+
+/**
+ * parse a RTF document from a reader in stream mode
+ * the first tokens of the document are buffered until the interpreter can be selected (the tokens where \fromhtml or \fromtext may appear)
+ */
+func parseTokenStream(reader io.Reader, w io.Writer, options rtfOptions, selectVisitor func(encapsulation RtfEncapsulation) (rtfStreamVisitor, error)) error {
+	var (
+		head      []RtfToken
+		inspected int
+		walker    *rtfStreamWalker
+	)
+
+	start := func() error {
+		if !tokensAreValid(head) {
+			return ErrNotRTF
+		}
+
+		visitor, err := selectVisitor(detectTokensEncapsulation(head))
+		if err != nil {
+			return err
+		}
+
+		walker = newRtfStreamWalker(visitor)
+		walker.visitor.startDocument(w)
+		for _, token := range head {
+			walker.handleToken(token)
+		}
+		head = nil
+		return nil
+	}
+
+	rtfObj := options.newStructure()
+	err := rtfObj.ParseStream(reader, func(token RtfToken) error {
+		if walker != nil {
+			walker.handleToken(token)
+			return nil
+		}
+
+		head = append(head, token)
+		if token.Type == RtfTokenGroupStart || token.Type == RtfTokenControlWord {
+			inspected++
+		}
+
+		if inspected >= rtfEncapsulationInspectedTokens || token.Name == "fromhtml" || token.Name == "fromtext" {
+			return start()
+		}
+		return nil
+	})
+
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	if walker == nil {
+		// a short document
+		if err := start(); err != nil {
+			return err
+		}
+	}
+
+	walker.finish()
+	return walker.visitor.endDocument()
+}
+
+/**
+ * the root group must have the first control word == rtf1
+ */
+func tokensAreValid(tokens []RtfToken) bool {
+	return len(tokens) >= 2 && tokens[0].Type == RtfTokenGroupStart &&
+		tokens[1].Type == RtfTokenControlWord && tokens[1].Name == "rtf" && tokens[1].Parameter == "1"
+}
+
+/**
+ * detect the encapsulated format from the first tokens of the document: the first \fromhtml or \fromtext control word
+ * found in the first 10 tokens (begin group marks and control words)
+ */
+func detectTokensEncapsulation(tokens []RtfToken) RtfEncapsulation {
+	inspected := 0
+	for _, token := range tokens {
+		if token.Type != RtfTokenGroupStart && token.Type != RtfTokenControlWord {
+			continue
+		}
+
+		if token.Type == RtfTokenControlWord {
+			switch token.Name {
+			case "fromhtml":
+				return RtfEncapsulationHtml
+			case "fromtext":
+				return RtfEncapsulationText
+			}
+		}
+
+		inspected++
+		if inspected >= rtfEncapsulationInspectedTokens {
+			break
+		}
+	}
+	return RtfEncapsulationNone
+}
@@ -0,0 +1,273 @@
+/**
+ * extract the styles of the document from the stylesheet
+ *
+ * {\stylesheet {\ql\f0\fs24 \snext0 Normal;} {\s1\b\fs32\outlinelevel0 \sbasedon0 \snext0 heading 1;}
+ * {\*\cs10 \additive Default Paragraph Font;} {\*\ts11\tsrowd ... Normal Table;}}
+ */
+
+package rtfconverter
+
+import (
+	"strconv"
+	"strings"
+)
+
+/**
+ * the style types: paragraph (\sN), character (\*\csN), table (\*\tsN) and section (\dsN) styles
+ */
+const (
+	RtfStyleParagraph = "paragraph"
+	RtfStyleCharacter = "character"
+	RtfStyleTable     = "table"
+	RtfStyleSection   = "section"
+)
+
+/**
+ * the number of the \sbasedon and \snext words for no style
+ */
+const rtfNoStyle = 222
+
+type RtfStyle struct {
+	Type   string
+	Number int
+	Name   string
+
+	// the style this style inherits the formatting from (\sbasedonN); -1 if the style is not based on another style
+	BasedOn int
+
+	// the style of the next paragraph (\snextN); the style itself if it is not defined
+	Next int
+
+	// a character style that is added to the paragraph formatting (\additive)
+	Additive bool
+
+	// the formatting words of the style, without the inherited formatting
+	Formatting []*ControlWord
+}
+
+/**
+ * the words of a style definition that are not formatting
+ */
+var rtfStyleDefinitionWords map[string]bool = map[string]bool{
+	"s":           true,
+	"cs":          true,
+	"ts":          true,
+	"ds":          true,
+	"sbasedon":    true,
+	"snext":       true,
+	"additive":    true,
+	"sautoupd":    true,
+	"shidden":     true,
+	"ssemihidden": true,
+	"sunhideused": true,
+	"sqformat":    true,
+	"spriority":   true,
+	"slink":       true,
+	"slocked":     true,
+	"spersonal":   true,
+	"scompose":    true,
+	"sreply":      true,
+	"styrsid":     true,
+}
+
+/**
+ * the styles of the document, in the stylesheet order
+ */
+func (rtfObj *RtfStructure) Styles() ([]RtfStyle, error) {
+	if !rtfObj.IsValid() {
+		return nil, ErrNotRTF
+	}
+
+	for _, child := range rtfObj.Root.GetChildren() {
+		if group, ok := child.(*Group); ok && group.IsStylesheet() {
+			return extractStyles(group, documentEncoding(rtfObj.Root)), nil
+		}
+	}
+
+	return nil, nil
+}
+
+func extractStyles(item *Group, encoding string) []RtfStyle {
+	var styles []RtfStyle
+
+	for _, child := range item.GetChildren() {
+		if group, ok := child.(*Group); ok {
+			styles = append(styles, extractStyle(group, encoding))
+		}
+	}
+
+	return styles
+}
+
+/**
+ * a style without \sN, \csN, \tsN or \dsN is the paragraph style 0
+ */
+func extractStyle(item *Group, encoding string) RtfStyle {
+	style := RtfStyle{Type: RtfStyleParagraph, BasedOn: -1, Next: -1}
+
+	for _, child := range item.GetChildren() {
+		word, ok := child.(*ControlWord)
+		if !ok {
+			continue
+		}
+
+		switch word.GetWord() {
+		case "s":
+			style.Type, style.Number = RtfStyleParagraph, word.GetIntParameter()
+		case "cs":
+			style.Type, style.Number = RtfStyleCharacter, word.GetIntParameter()
+		case "ts":
+			style.Type, style.Number = RtfStyleTable, word.GetIntParameter()
+		case "ds":
+			style.Type, style.Number = RtfStyleSection, word.GetIntParameter()
+		case "sbasedon":
+			if word.GetIntParameter() != rtfNoStyle {
+				style.BasedOn = word.GetIntParameter()
+			}
+		case "snext":
+			style.Next = word.GetIntParameter()
+		case "additive":
+			style.Additive = true
+		}
+
+		if !rtfStyleDefinitionWords[word.GetWord()] {
+			style.Formatting = append(style.Formatting, word)
+		}
+	}
+
+	if style.Next < 0 {
+		style.Next = style.Number
+	}
+
+	style.Name = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(decodeGroupText(item, encoding)), ";"))
+
+	return style
+}
+
+/**
+ * the styles of the stylesheet by type and number, used by the interpreters to resolve the formatting of the text
+ */
+type rtfStylesheet struct {
+	styles map[string]map[int]RtfStyle
+}
+
+func newRtfStylesheet(styles []RtfStyle) rtfStylesheet {
+	st := rtfStylesheet{styles: map[string]map[int]RtfStyle{}}
+
+	for _, style := range styles {
+		if st.styles[style.Type] == nil {
+			st.styles[style.Type] = map[int]RtfStyle{}
+		}
+		st.styles[style.Type][style.Number] = style
+	}
+
+	return st
+}
+
+func (st rtfStylesheet) style(styleType string, number int) (RtfStyle, bool) {
+	style, ok := st.styles[styleType][number]
+	return style, ok
+}
+
+/**
+ * the formatting of the style: the formatting of the base styles followed by the formatting of the style
+ */
+func (st rtfStylesheet) formatting(styleType string, number int) []*ControlWord {
+	var (
+		chain []RtfStyle
+		seen  = map[int]bool{}
+	)
+
+	for number >= 0 && !seen[number] {
+		style, ok := st.style(styleType, number)
+		if !ok {
+			break
+		}
+
+		seen[number] = true
+		chain = append(chain, style)
+		number = style.BasedOn
+	}
+
+	var formatting []*ControlWord
+	for i := len(chain) - 1; i >= 0; i-- {
+		formatting = append(formatting, chain[i].Formatting...)
+	}
+	return formatting
+}
+
+/**
+ * the html tag of a paragraph with the style: h1 - h6 for the headings (the heading N name, or the outline level
+ * of the style), blockquote for the quotes and p for the other paragraphs; the other styles, except the default
+ * paragraph style, are written as css classes
+ */
+func (st rtfStylesheet) htmlParagraph(number int) (tag string, class string) {
+	style, ok := st.style(RtfStyleParagraph, number)
+	if !ok {
+		return "p", ""
+	}
+
+	for _, name := range strings.Split(strings.ToLower(style.Name), ",") {
+		name = strings.TrimSpace(name)
+		if strings.HasPrefix(name, "heading ") {
+			if level, err := strconv.Atoi(strings.TrimPrefix(name, "heading ")); err == nil && level >= 1 && level <= 6 {
+				return "h" + strconv.Itoa(level), ""
+			}
+		}
+		if name == "quote" || name == "intense quote" || name == "block text" {
+			return "blockquote", ""
+		}
+	}
+
+	// the outline level of the style is the heading level (the names of the heading styles are translated)
+	outlineLevel := -1
+	for _, word := range st.formatting(RtfStyleParagraph, number) {
+		if word.GetWord() == "outlinelevel" {
+			outlineLevel = word.GetIntParameter()
+		}
+	}
+	if outlineLevel >= 0 && outlineLevel < 6 {
+		return "h" + strconv.Itoa(outlineLevel+1), ""
+	}
+
+	if number == 0 {
+		return "p", ""
+	}
+	return "p", cssClassName(style.Name)
+}
+
+/**
+ * the css class of a character style; empty if the style is not defined
+ */
+func (st rtfStylesheet) htmlCharacterClass(number int) string {
+	style, ok := st.style(RtfStyleCharacter, number)
+	if !ok || strings.EqualFold(style.Name, "Default Paragraph Font") {
+		return ""
+	}
+	return cssClassName(style.Name)
+}
+
+/**
+ * the style name as a css class: the lower case letters and digits, the other chars are replaced with -
+ */
+func cssClassName(name string) string {
+	class := strings.Builder{}
+	dash := false
+
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && class.Len() > 0 {
+				class.WriteByte('-')
+			}
+			class.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+
+	if class.Len() > 0 && class.String()[0] >= '0' && class.String()[0] <= '9' {
+		return "style-" + class.String()
+	}
+	return class.String()
+}
@@ -0,0 +1,167 @@
+package rtfconverter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testStylesheet = "{\\stylesheet{\\ql\\f0\\fs24\\snext0 Normal;}" +
+	"{\\s1\\b\\fs32\\sbasedon0\\snext0 heading 1;}" +
+	"{\\s2\\i\\sbasedon3 Loop A;}{\\s3\\ul\\sbasedon2 Loop B;}{\\s4\\strike\\sbasedon4 Self;}" +
+	"{\\s5\\outlinelevel1\\sbasedon0 \\'dcberschrift;}{\\s6\\sbasedon5 Sub Heading;}{\\s7\\sbasedon9 Missing;}" +
+	"{\\s8 Quote;}{\\*\\cs10\\additive\\b Strong Text;}{\\*\\cs11\\additive Default Paragraph Font;}" +
+	"{\\*\\ts12\\tsrowd\\trftsWidthB3\\sbasedon222 Normal Table;}}"
+
+/**
+ * the formatting words as rtf
+ */
+func styleFormatting(words []*ControlWord) string {
+	result := strings.Builder{}
+	for _, word := range words {
+		result.WriteString("\\" + word.GetWord() + word.GetParameter())
+	}
+	return result.String()
+}
+
+func TestStyles(t *testing.T) {
+	var rtfObj RtfStructure
+	if err := rtfObj.ParseBytes([]byte("{\\rtf1\\ansi\\ansicpg1252" + testStylesheet + "\\pard text\\par}")); err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	styles, err := rtfObj.Styles()
+	if err != nil {
+		t.Fatalf("styles failed: %v", err)
+	}
+	if len(styles) != 12 {
+		t.Fatalf("got %d styles", len(styles))
+	}
+
+	tests := []struct {
+		idx        int
+		styleType  string
+		number     int
+		name       string
+		basedOn    int
+		next       int
+		additive   bool
+		formatting string
+	}{
+		{0, RtfStyleParagraph, 0, "Normal", -1, 0, false, "\\ql\\f0\\fs24"},
+		{1, RtfStyleParagraph, 1, "heading 1", 0, 0, false, "\\b\\fs32"},
+		{5, RtfStyleParagraph, 5, "Überschrift", 0, 5, false, "\\outlinelevel1"},
+		{9, RtfStyleCharacter, 10, "Strong Text", -1, 10, true, "\\b"},
+		{11, RtfStyleTable, 12, "Normal Table", -1, 12, false, "\\tsrowd\\trftsWidthB3"},
+	}
+	for _, test := range tests {
+		style := styles[test.idx]
+		if style.Type != test.styleType || style.Number != test.number || style.Name != test.name || style.BasedOn != test.basedOn ||
+			style.Next != test.next || style.Additive != test.additive || styleFormatting(style.Formatting) != test.formatting {
+			t.Fatalf("style %d: got %+v with formatting %s", test.idx, style, styleFormatting(style.Formatting))
+		}
+	}
+}
+
+func TestStylesheetFormatting(t *testing.T) {
+	var rtfObj RtfStructure
+	if err := rtfObj.ParseBytes([]byte("{\\rtf1" + testStylesheet + "}")); err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	styles, _ := rtfObj.Styles()
+	stylesheet := newRtfStylesheet(styles)
+
+	tests := []struct {
+		styleType  string
+		number     int
+		formatting string
+	}{
+		// the formatting of the base style is first
+		{RtfStyleParagraph, 1, "\\ql\\f0\\fs24\\b\\fs32"},
+		{RtfStyleParagraph, 6, "\\ql\\f0\\fs24\\outlinelevel1"},
+		// each style of a cycle is used once
+		{RtfStyleParagraph, 2, "\\ul\\i"},
+		{RtfStyleParagraph, 3, "\\i\\ul"},
+		{RtfStyleParagraph, 4, "\\strike"},
+		// the missing base style is ignored
+		{RtfStyleParagraph, 7, ""},
+		{RtfStyleParagraph, 99, ""},
+		{RtfStyleCharacter, 10, "\\b"},
+		{RtfStyleCharacter, 1, ""},
+	}
+	for _, test := range tests {
+		if formatting := styleFormatting(stylesheet.formatting(test.styleType, test.number)); formatting != test.formatting {
+			t.Fatalf("%s style %d: got %s, expected %s", test.styleType, test.number, formatting, test.formatting)
+		}
+	}
+
+	paragraphs := []struct {
+		number int
+		tag    string
+		class  string
+	}{
+		{0, "p", ""},
+		{1, "h1", ""},
+		{2, "p", "loop-a"},
+		// the outline level is inherited
+		{6, "h2", ""},
+		{8, "blockquote", ""},
+		{99, "p", ""},
+	}
+	for _, test := range paragraphs {
+		if tag, class := stylesheet.htmlParagraph(test.number); tag != test.tag || class != test.class {
+			t.Fatalf("style %d: got %s %q, expected %s %q", test.number, tag, class, test.tag, test.class)
+		}
+	}
+
+	if class := stylesheet.htmlCharacterClass(10); class != "strong-text" {
+		t.Fatalf("got character class %q", class)
+	}
+	if class := stylesheet.htmlCharacterClass(11); class != "" {
+		t.Fatalf("got default font class %q", class)
+	}
+}
+
+func TestCssClassName(t *testing.T) {
+	tests := map[string]string{
+		"Normal":         "normal",
+		"  Block  Text ": "block-text",
+		"Überschrift 1":  "berschrift-1",
+		"1 Column":       "style-1-column",
+		"---":            "",
+	}
+	for name, class := range tests {
+		if got := cssClassName(name); got != class {
+			t.Fatalf("%q: got %q, expected %q", name, got, class)
+		}
+	}
+}
+
+func TestStylesConversion(t *testing.T) {
+	rtf := "{\\rtf1" + testStylesheet + "\\pard\\s1 Title\\par\\pard\\s2 loop\\par\\pard\\s4 self\\par\\pard plain {\\cs10 strong}\\par}"
+
+	html := convertRtf(t, []byte(rtf), "html")
+	expected := "<h1><span style=\"font-weight:bold;font-size:16pt;\">Title</span></h1>\r\n" +
+		"<p class=\"loop-a\"><span style=\"font-style:italic;text-decoration:underline;\">loop</span></p>\r\n" +
+		"<p class=\"self\"><span style=\"text-decoration:line-through;\">self</span></p>\r\n" +
+		"<p>plain <span class=\"strong-text\" style=\"font-weight:bold;\">strong</span></p>\r\n"
+	if html != expected {
+		t.Fatalf("got %q, expected %q", html, expected)
+	}
+}
+
+func TestStylesErrors(t *testing.T) {
+	var rtfObj RtfStructure
+	if err := rtfObj.ParseBytes([]byte("{\\rtf1 text{\\shp{\\stylesheet{\\s1 Nested;}}}}")); err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	// a stylesheet that is not a child of the root group is ignored
+	if styles, err := rtfObj.Styles(); err != nil || styles != nil {
+		t.Fatalf("got %v, %v", styles, err)
+	}
+
+	var invalid RtfStructure
+	invalid.ParseBytes([]byte("{\\stylesheet{\\s1 a;}}"))
+	if _, err := invalid.Styles(); !errors.Is(err, ErrNotRTF) {
+		t.Fatalf("expected %v, got %v", ErrNotRTF, err)
+	}
+}
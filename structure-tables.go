@@ -0,0 +1,111 @@
+/**
+ * extract the document tables (font table, color table) from their groups
+ */
+
+package rtfconverter
+
+import (
+	"bytes"
+)
+
+/**
+ * 	extract font table
+ *   {' \fonttbl (<fontinfo> | ('{' <fontinfo> '}'))+ '}'
+ *   <fontnum><fontfamily><fcharset>?<fprq>?<panose>?
+ *   <nontaggedname>?<fontemb>?<codepage>? <fontname><fontaltname>? ';'
+ *
+ * both forms are accepted: each fontinfo in its own group, or all the fontinfo entries directly in the fonttbl group
+ */
+func extractFontTable(item *Group) map[int]*rtfFontTableItem {
+	fontTable := map[int]*rtfFontTableItem{}
+	fontIdx := -1
+
+	for _, child := range item.GetChildren() {
+		switch cobj := child.(type) {
+		case *Group:
+			if cobj.IsFontInfo() {
+				extractFontInfo(cobj, fontTable)
+			}
+		case *ControlWord, *Text:
+			fontIdx = extractFontInfoElement(child, fontIdx, fontTable)
+		}
+	}
+
+	return fontTable
+}
+
+func extractFontInfo(item *Group, fontTable map[int]*rtfFontTableItem) {
+	fontIdx := -1
+	for _, child := range item.GetChildren() {
+		fontIdx = extractFontInfoElement(child, fontIdx, fontTable)
+	}
+}
+
+/**
+ * apply a single element of a fontinfo entry to the font table; return the index of the font currently described
+ */
+func extractFontInfoElement(child Element, fontIdx int, fontTable map[int]*rtfFontTableItem) int {
+	switch cobj := child.(type) {
+	case *ControlWord:
+		switch cobj.GetWord() {
+		case "f":
+			fontIdx = cobj.GetIntParameter()
+			fontTable[fontIdx] = &rtfFontTableItem{}
+		case "fnil", "froman", "fswiss", "fmodern", "fscript", "fdecor", "ftech", "fbidi":
+			// font fammily
+			if ftItem, ok := fontTable[fontIdx]; ok {
+				ftItem.familyCode = cobj.GetWord()
+			}
+		case "fcharset":
+			if ftItem, ok := fontTable[fontIdx]; ok {
+				ftItem.charsetIndex = cobj.GetIntParameter()
+			}
+		}
+	case *Text:
+		if ftItem, ok := fontTable[fontIdx]; ok {
+			ftItem.familyName += string(bytes.TrimRight(cobj.GetContent(), ";"))
+		}
+	case *Group:
+		if cobj.IsFontAlternative() && len(cobj.children) >= 3 {
+			// {\*\falt xxxx}
+			if text, ok := cobj.children[2].(*Text); ok {
+				if ftItem, ok := fontTable[fontIdx]; ok {
+					ftItem.familyAlternativeName = string(text.GetContent())
+				}
+			}
+		}
+	}
+
+	return fontIdx
+}
+
+/**
+ * extract colors from colortbl tag
+ *  {\colortbl;\red0\green0\blue0;}
+ * every ; ends a color, so an entry without any color word (usually the first one) is the 'auto' color
+ */
+func extractColorTable(item *Group) []rtfColor {
+	var colorTable []rtfColor
+
+	color := rtfColor{}
+	for _, child := range item.GetChildren() {
+		switch cobj := child.(type) {
+		case *ControlWord:
+			switch cobj.GetWord() {
+			case "red":
+				color.r = cobj.GetIntParameter()
+			case "green":
+				color.g = cobj.GetIntParameter()
+			case "blue":
+				color.b = cobj.GetIntParameter()
+			}
+		case *Text:
+			for i := 0; i < bytes.Count(cobj.GetContent(), []byte(";")); i++ {
+				colorTable = append(colorTable, color)
+				color = rtfColor{}
+			}
+		}
+	}
+
+	return colorTable
+}
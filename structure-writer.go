@@ -0,0 +1,154 @@
+/**
+ * write the tree of a RTF document back to RTF
+ */
+
+package rtfconverter
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+type rtfWriter struct {
+	content *bufio.Writer
+
+	// the \ucN value of each opened group: how many replacement chars are written after \uN
+	uc []int
+
+	// the last written token is a control word; a space must delimit it from the next text
+	delimiterNeeded bool
+}
+
+/**
+ * write the document to w
+ */
+func (rtfObj *RtfStructure) Write(w io.Writer) error {
+	if rtfObj.Root == nil {
+		return ErrNotRTF
+	}
+
+	writer := rtfWriter{content: bufio.NewWriter(w)}
+	writer.writeElement(rtfObj.Root)
+
+	return writer.content.Flush()
+}
+
+/**
+ * return the document as RTF
+ */
+func (rtfObj *RtfStructure) Bytes() ([]byte, error) {
+	buffer := bytes.Buffer{}
+
+	if err := rtfObj.Write(&buffer); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (wr *rtfWriter) writeElement(item Element) {
+	switch cobj := item.(type) {
+	case *Group:
+		wr.writeGroup(cobj)
+	case *ControlWord:
+		wr.writeControlWord(cobj)
+	case *ControlSymbol:
+		wr.writeControlSymbol(cobj)
+	case *Text:
+		wr.writeText(cobj.GetContent())
+	case *Binary:
+		wr.writeBinary(cobj.GetData())
+	}
+}
+
+func (wr *rtfWriter) writeGroup(item *Group) {
+	if len(wr.uc) == 0 {
+		wr.uc = append(wr.uc, 1)
+	} else {
+		// the \uc value is inherited from the parent group
+		wr.uc = append(wr.uc, wr.uc[len(wr.uc)-1])
+	}
+
+	wr.content.WriteByte('{')
+	wr.delimiterNeeded = false
+
+	for _, child := range item.GetChildren() {
+		wr.writeElement(child)
+	}
+
+	wr.content.WriteByte('}')
+	wr.delimiterNeeded = false
+
+	wr.uc = wr.uc[:len(wr.uc)-1]
+}
+
+/**
+ * the tokenizer skips the replacement chars of \uN; write the ? replacement char \ucN times, so the text that follows is not skipped
+ */
+func (wr *rtfWriter) writeControlWord(item *ControlWord) {
+	wr.content.WriteByte('\\')
+	wr.content.WriteString(item.GetWord())
+	wr.content.WriteString(item.GetParameter())
+	wr.delimiterNeeded = true
+
+	switch item.GetWord() {
+	case "uc":
+		wr.uc[len(wr.uc)-1] = item.GetIntParameter()
+	case "u":
+		if uc := wr.uc[len(wr.uc)-1]; uc > 0 {
+			wr.content.Write(bytes.Repeat([]byte("?"), uc))
+			wr.delimiterNeeded = false
+		}
+	}
+}
+
+func (wr *rtfWriter) writeControlSymbol(item *ControlSymbol) {
+	wr.content.WriteByte('\\')
+	wr.content.WriteString(item.GetSymbol())
+	wr.content.WriteString(item.GetParameter())
+	wr.delimiterNeeded = false
+}
+
+/**
+ * the text content keeps the escaped chars (\{, \}, \\) as they were read; the unescaped delimiters are escaped and the
+ * non ASCII bytes (text in the document code page) are written as \'HH
+ *
+ * the written document is 7-bit: a text with non ASCII bytes is parsed back as texts and \' control symbols, that
+ * decode to the same chars; the document written from that tree is the same, so parse -> write is stable after the first write
+ */
+func (wr *rtfWriter) writeText(content []byte) {
+	if len(content) == 0 {
+		return
+	}
+
+	// \'HH ends the control word, no delimiter is needed (the tree parsed back has a control symbol after the word)
+	if wr.delimiterNeeded && content[0] < 0x80 {
+		wr.content.WriteByte(' ')
+		wr.delimiterNeeded = false
+	}
+
+	for i := 0; i < len(content); i++ {
+		b := content[i]
+		switch {
+		case b == '\\' && i+1 < len(content) && (content[i+1] == '\\' || content[i+1] == '{' || content[i+1] == '}'):
+			wr.content.WriteByte(b)
+			wr.content.WriteByte(content[i+1])
+			i++
+		case b == '\\' || b == '{' || b == '}':
+			wr.content.WriteByte('\\')
+			wr.content.WriteByte(b)
+		case b >= 0x80:
+			fmt.Fprintf(wr.content, "\\'%02x", b)
+		default:
+			wr.content.WriteByte(b)
+		}
+	}
+}
+
+func (wr *rtfWriter) writeBinary(data []byte) {
+	fmt.Fprintf(wr.content, "\\bin%d ", len(data))
+	wr.content.Write(data)
+	wr.delimiterNeeded = false
+}
@@ -15,8 +15,8 @@ import (
 
 type RtfStructure struct {
 	reader *bufio.Reader
-	Root *rtfGroup
-	currentGroup *rtfGroup
+	Root *Group
+	currentGroup *Group
 
 	/**
 	 * This keyword represents the number of bytes corresponding to a given \uN Unicode character.
@@ -32,45 +32,202 @@ type RtfStructure struct {
 	 */
 
 	uc []int
+
+	// how many groups are opened; the document ends when the root group is closed
+	depth int
+	rootClosed bool
+
+	// in stream mode the tokens are sent to the handler and the tree is not built
+	tokenHandler RtfTokenHandler
+
+	// the error that stopped the parsing
+	err error
+
+	// the maximum size in bytes and the maximum group depth of the document; 0 - no limit
+	maxSize int64
+	maxDepth int
+
+	// position of the reader: bytes read, line and column (from 1) of the next byte
+	offset int64
+	line int
+	column int
+	previousColumn int
+}
+
+/**
+ * the format a RTF document was produced from
+ */
+type RtfEncapsulation string
+
+const (
+	// a native RTF document
+	RtfEncapsulationNone RtfEncapsulation = "rtf"
+
+	// the RTF encapsulates a html document (\fromhtml)
+	RtfEncapsulationHtml RtfEncapsulation = "html"
+
+	// the RTF was produced from a plain text document (\fromtext)
+	RtfEncapsulationText RtfEncapsulation = "text"
+)
+
+/**
+ * the token types emitted by the tokenizer
+ */
+type RtfTokenType int
+
+const (
+	RtfTokenGroupStart RtfTokenType = iota
+	RtfTokenGroupEnd
+	RtfTokenControlWord
+	RtfTokenControlSymbol
+	RtfTokenText
+	RtfTokenBinary
+)
+
+/**
+ * a single token of the RTF document
+ * Name is the control word or the control symbol without \, Parameter is the control word parameter or the HH digits of \'HH
+ * Content is the text of a text token or the data of a binary token (\binN)
+ * in stream mode a long text or \binN data is sent as several consecutive tokens; each binary token has its size as Parameter
+ */
+type RtfToken struct {
+	Type      RtfTokenType
+	Name      string
+	Parameter string
+	Content   []byte
 }
 
+/**
+ * receive the tokens in stream mode; returning an error stops the parsing
+ */
+type RtfTokenHandler func(token RtfToken) error
+
+/**
+ * in stream mode a long text (eg: the hex data of a picture) or the data of a long \binN is split into tokens of maximum this size,
+ * so the memory used is bounded
+ */
+const rtfStreamTextChunkSize = 64 * 1024
+
 /**
  * load a file
  * @param {[type]} file string [description]
  */
 func (rtfObj *RtfStructure) ParseFile(filename string) (error) {
-	_, err := os.Stat(filename)
-	if err != nil {
-		return err
-	}
-
 	fileReader, err := os.Open(filename)
-	defer fileReader.Close()
-
-/*
-	data, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return err
 	}
-*/
-	reader := bufio.NewReader(fileReader)
+	defer fileReader.Close()
 
-	rtfObj.setReader(reader)
-	return rtfObj.Parse()
+	return rtfObj.ParseReader(fileReader)
 }
 
 func (rtfObj *RtfStructure) ParseBytes(content []byte) (error) {
+	return rtfObj.ParseReader(bytes.NewReader(content))
+}
 
-	btsReader := bytes.NewReader(content)
-
-	reader := bufio.NewReader(btsReader)
-	rtfObj.setReader(reader)
+/**
+ * parse the RTF document from a reader and build the tree of groups
+ */
+func (rtfObj *RtfStructure) ParseReader(reader io.Reader) (error) {
+	rtfObj.setReader(bufio.NewReader(reader))
 	return rtfObj.Parse()
 }
 
+/**
+ * parse the RTF document from a reader in stream mode: the tokens are sent to the handler as they are read and the tree is not built,
+ * so the document is never entirely in memory
+ */
+func (rtfObj *RtfStructure) ParseStream(reader io.Reader, handler RtfTokenHandler) (error) {
+	rtfObj.tokenHandler = handler
+	defer func() {
+		rtfObj.tokenHandler = nil
+	}()
+
+	return rtfObj.ParseReader(reader)
+}
+
 
 func (rtfObj *RtfStructure) setReader(reader *bufio.Reader) {
 	rtfObj.reader = reader
+	rtfObj.offset = 0
+	rtfObj.line = 1
+	rtfObj.column = 1
+}
+
+/**
+ * read a byte and keep the position of the reader
+ */
+func (rtfObj *RtfStructure) readByte() (byte, error) {
+	b, err := rtfObj.reader.ReadByte()
+	if err != nil {
+		return b, err
+	}
+
+	rtfObj.offset++
+	if rtfObj.maxSize > 0 && rtfObj.offset > rtfObj.maxSize {
+		return 0, rtfObj.parseError(ErrInputSizeExceeded)
+	}
+	rtfObj.previousColumn = rtfObj.column
+	if b == '\n' {
+		rtfObj.line++
+		rtfObj.column = 1
+	} else {
+		rtfObj.column++
+	}
+	return b, nil
+}
+
+/**
+ * move the reader back with the last read byte (only a single byte can be unread)
+ */
+func (rtfObj *RtfStructure) unreadByte() (error) {
+	if err := rtfObj.reader.UnreadByte(); err != nil {
+		return err
+	}
+
+	rtfObj.offset--
+	if rtfObj.column == 1 && rtfObj.previousColumn > 0 {
+		rtfObj.line--
+	}
+	rtfObj.column = rtfObj.previousColumn
+	return nil
+}
+
+/**
+ * read an entire char (if is multibyte) and keep the position of the reader
+ */
+func (rtfObj *RtfStructure) readRune() (rune, error) {
+	r, size, err := rtfObj.reader.ReadRune()
+	if err != nil {
+		return r, err
+	}
+
+	rtfObj.offset += int64(size)
+	if rtfObj.maxSize > 0 && rtfObj.offset > rtfObj.maxSize {
+		return 0, rtfObj.parseError(ErrInputSizeExceeded)
+	}
+	rtfObj.previousColumn = rtfObj.column
+	if r == '\n' {
+		rtfObj.line++
+		rtfObj.column = 1
+	} else {
+		rtfObj.column++
+	}
+	return r, nil
+}
+
+/**
+ * create an error with the current position of the reader
+ */
+func (rtfObj *RtfStructure) parseError(err error) (*ParseError) {
+	return &ParseError{
+		Offset: rtfObj.offset,
+		Line:   rtfObj.line,
+		Column: rtfObj.column,
+		Depth:  rtfObj.depth,
+		Err:    err,
+	}
 }
 
 
@@ -80,65 +237,155 @@ func (rtfObj *RtfStructure) Parse() (error){
 		err error
 	)
 
+	// a structure can parse several documents: the tree and the state of the previous document are dropped
+	rtfObj.Root = nil
+	rtfObj.currentGroup = nil
+	rtfObj.uc = nil
+	rtfObj.depth = 0
+	rtfObj.rootClosed = false
+	rtfObj.err = nil
+
 	for {
 		// read a single line
 
-		b, err = rtfObj.reader.ReadByte()
+		b, err = rtfObj.readByte()
 
 		//fmt.Println("Read: ", string(b))
 
-		if (rtfObj.currentGroup == nil && rtfObj.Root != nil) {
+		if (rtfObj.rootClosed) {
 			// ignore text after RTF group tag is closed
 			break
 		}
 
+		if err != nil {
+			// If we're just at the EOF, break
+			if err != io.EOF {
+				return err
+			}
+			if rtfObj.depth == 0 {
+				// the root group was not found
+				return rtfObj.parseError(ErrNotRTF)
+			}
+			if rtfObj.depth > 0 {
+				// the document ends before the root group is closed
+				return rtfObj.parseError(ErrUnbalancedGroups)
+			}
+			break
+		}
+
+		if rtfObj.depth == 0 && string(b) != "{" {
+			// only white spaces are accepted before the root group
+			if b == ' ' || b == '\t' || b == '\r' || b == '\n' {
+				continue
+			}
+			if string(b) == "}" {
+				return rtfObj.parseError(ErrUnbalancedGroups)
+			}
+			return rtfObj.parseError(ErrNotRTF)
+		}
+
 		// What type of character is this?
 		switch {
 			case string(b) == "{":
 			  rtfObj.startGroup();
+			  if rtfObj.depth == 1 {
+				  // the root group must start with \rtf
+				  if bp, _ := rtfObj.reader.Peek(4); string(bp) != "\\rtf" {
+					  return rtfObj.parseError(ErrNotRTF)
+				  }
+			  }
 			case string(b) == "}":
 			  rtfObj.endGroup();
 			case string(b) == "\\":
 			  rtfObj.parseControl();
 			default:
 			  // move the pointer back 1 char
-			  rtfObj.reader.UnreadByte()
+			  rtfObj.unreadByte()
 			  rtfObj.parseText()
 		}
-		// If we're just at the EOF, break
-		if err != nil || err == io.EOF {
-		    break
+
+		if rtfObj.err != nil {
+			return rtfObj.err
 		}
+	}
+
+	 return nil
+
+}
 
+/**
+ * send a token to the stream handler, or add it to the tree if the stream mode is not used
+ */
+func (rtfObj *RtfStructure) emit(token RtfToken) {
+	if rtfObj.err != nil {
+		return
 	}
 
-	 return err
+	if rtfObj.tokenHandler != nil {
+		rtfObj.err = rtfObj.tokenHandler(token)
+		return
+	}
+
+	switch token.Type {
+	case RtfTokenGroupStart:
+		group := &Group{}
+		if rtfObj.Root == nil {
+			rtfObj.Root = group
+		} else if rtfObj.currentGroup != nil {
+			// add the new group as a child to the current one
+			rtfObj.currentGroup.addChild(group)
+		}
+
+		// set the active group the new one
+		rtfObj.currentGroup = group
+	case RtfTokenGroupEnd:
+		// when a group is closed, set the new current group his parent
+		if rtfObj.currentGroup != nil {
+			rtfObj.currentGroup = rtfObj.currentGroup.GetParent()
+		}
+	default:
+		if rtfObj.currentGroup != nil {
+			rtfObj.currentGroup.addChild(tokenElement(token))
+		}
+	}
+}
 
+/**
+ * create the tree element of a control word, control symbol or text token
+ */
+func tokenElement(token RtfToken) (Element) {
+	switch token.Type {
+	case RtfTokenControlWord:
+		return &ControlWord{word: token.Name, parameter: token.Parameter}
+	case RtfTokenControlSymbol:
+		return &ControlSymbol{symbol: token.Name, parameter: token.Parameter}
+	case RtfTokenText:
+		return &Text{content: token.Content}
+	case RtfTokenBinary:
+		return &Binary{data: token.Content}
+	}
+	return nil
 }
 
 /**
- * create a new group if the current char is {, and add it as a child to current group, and set the current group the new created group
- *
- * @param  {[type]} rtfObj *RtfStructure)   parseStartGroup( [description]
- * @return {[type]}        [description]
+ * a new group starts if the current char is {
+ * the \uc value is inherited from the parent group
  */
 func (rtfObj *RtfStructure) startGroup() {
 	//fmt.Println("start group")
-	group := rtfGroup{}
-	if rtfObj.Root == nil {
-		rtfObj.Root = &group
-		rtfObj.currentGroup = rtfObj.Root
+	if len(rtfObj.uc) == 0 {
 		rtfObj.uc = append(rtfObj.uc, 1)
 	} else {
-		// add the new group as a child to the current one
-		rtfObj.currentGroup.addChild(&group)
-
-		// set the active group the new one
-		rtfObj.currentGroup = &group
-
 		// inherit the uc from the last group
 		rtfObj.uc = append(rtfObj.uc, rtfObj.uc[len(rtfObj.uc)-1])
 	}
+
+	rtfObj.depth++
+	if rtfObj.maxDepth > 0 && rtfObj.depth > rtfObj.maxDepth {
+		rtfObj.err = rtfObj.parseError(ErrMaxDepthExceeded)
+		return
+	}
+	rtfObj.emit(RtfToken{Type: RtfTokenGroupStart})
 }
 
 
@@ -149,21 +396,28 @@ func (rtfObj *RtfStructure) startGroup() {
 func (rtfObj *RtfStructure) endGroup() {
 	//fmt.Println("end group")
 
-	// when a group is closed, set the new current group his parent
-	rtfObj.currentGroup = rtfObj.currentGroup.GetParent()
-
 	// uc for the group is lost when the group is closed, and the previous value is restored
 	if (len(rtfObj.uc) > 0) {
 		rtfObj.uc = rtfObj.uc[:len(rtfObj.uc)-1]
 	}
 
+	if rtfObj.depth == 0 {
+		// unbalanced }
+		return
+	}
+
+	rtfObj.depth--
+	if rtfObj.depth == 0 {
+		rtfObj.rootClosed = true
+	}
+
+	rtfObj.emit(RtfToken{Type: RtfTokenGroupEnd})
 }
 
 func (rtfObj *RtfStructure) parseText() {
 
 	var (
 		b byte
-		br rune
 		bp []byte
 		err error
 	)
@@ -173,6 +427,11 @@ func (rtfObj *RtfStructure) parseText() {
 
 	// continue read until meet a char that tell us the text ends (start group, end group, control word , control symbol)
 	for {
+		if rtfObj.tokenHandler != nil && buffer.Len() >= rtfStreamTextChunkSize {
+			// in stream mode the long text is sent in chunks
+			break
+		}
+
 		// peek to the next 2 bytes
 		bp, err = rtfObj.reader.Peek(2)
 
@@ -185,7 +444,7 @@ func (rtfObj *RtfStructure) parseText() {
 
 		// ignore EOL chars
 		if (len(bp)>=1 && (string(bp[0]) == "\r" || string(bp[0]) == "\n" )) {
-			_, err = rtfObj.reader.ReadByte()
+			_, err = rtfObj.readByte()
 			if err != nil {
 				break
 			}
@@ -204,14 +463,14 @@ func (rtfObj *RtfStructure) parseText() {
 				 */
 
 				// read the escape char (\)
-				b, err = rtfObj.reader.ReadByte()
+				b, err = rtfObj.readByte()
 				if err != nil {
 					break
 				}
 				buffer.WriteByte(b)
 
 				// read the escaped char
-				b, err = rtfObj.reader.ReadByte()
+				b, err = rtfObj.readByte()
 				if err != nil {
 					break
 				}
@@ -224,20 +483,18 @@ func (rtfObj *RtfStructure) parseText() {
 			break
 		}
 
-		// if it get here it means is a text char
-		br, _, err = rtfObj.reader.ReadRune()
+		// if it get here it means is a text char; the bytes are kept as they are, the text is decoded with the document code page
+		b, err = rtfObj.readByte()
 		if err != nil {
 			break;
 		}
-		buffer.WriteRune(br)
+		buffer.WriteByte(b)
 	}
 
 	if buffer.Len() > 0 {
 		// append text token only if the text if not empty
-		obj := &rtfText{content: buffer.Bytes()}
-
-		//fmt.Println("write text: ", string(obj.content))
-		rtfObj.currentGroup.addChild(obj)
+		//fmt.Println("write text: ", buffer.String())
+		rtfObj.emit(RtfToken{Type: RtfTokenText, Content: buffer.Bytes()})
 	}
 
 }
@@ -295,7 +552,7 @@ func (rtfObj *RtfStructure) parseControlSymbol() {
 	  parameterBuffer := &bytes.Buffer{}
 
 
-      b, err := rtfObj.reader.ReadByte()
+      b, err := rtfObj.readByte()
 
       if (err != nil) {
       	return
@@ -315,15 +572,14 @@ func (rtfObj *RtfStructure) parseControlSymbol() {
 		}
 
 		if (len(bp)==1 &&  (string(bp[0]) == "\r" || string(bp[0]) == "\n")) {
-			_, err := rtfObj.reader.ReadByte()
+			_, err := rtfObj.readByte()
 			if err != nil {
 				// probably is end of file
 				return
 			}
 		}
 
-        obj := &rtfControlWord{word: "par"}
-        rtfObj.currentGroup.addChild(obj)
+        rtfObj.emit(RtfToken{Type: RtfTokenControlWord, Name: "par"})
         return;
 
       } else if(string(b) == "'") {
@@ -332,20 +588,19 @@ func (rtfObj *RtfStructure) parseControlSymbol() {
         bp, err = rtfObj.reader.Peek(1)
         if (err == nil && ByteIsHexDigit(bp[0])) {
         	// read first hexDigit
-    		rtfObj.reader.ReadByte()
+    		rtfObj.readByte()
     		parameterBuffer.WriteByte(bp[0])
 
     		// read second hexDigit
     		bp, err = rtfObj.reader.Peek(1)
     		if (err == nil && ByteIsHexDigit(bp[0])) {
-	    		rtfObj.reader.ReadByte()
+	    		rtfObj.readByte()
 	    		parameterBuffer.WriteByte(bp[0])
     		}
         }
       }
 
-      objSymbol := &rtfControlSymbol{symbol: string(b), parameter: parameterBuffer.String()};
-      rtfObj.currentGroup.addChild(objSymbol)
+      rtfObj.emit(RtfToken{Type: RtfTokenControlSymbol, Name: string(b), Parameter: parameterBuffer.String()})
 }
 
 
@@ -370,6 +625,8 @@ func (rtfObj *RtfStructure) parseControlWord() {
 		wordBuffer := &bytes.Buffer{}
 		parameterBuffer := &bytes.Buffer{}
 
+		position := rtfObj.controlPosition()
+
 		// extract the word
 		for {
 			bp, err = rtfObj.reader.Peek(1)
@@ -378,7 +635,7 @@ func (rtfObj *RtfStructure) parseControlWord() {
 			}
 
 			if (ByteIsAsciiLetter(bp[0])) {
-				b,err = rtfObj.reader.ReadByte()
+				b,err = rtfObj.readByte()
 				//fmt.Println("Read extract word: ",string(b))
 				if (err != nil) {
 					break
@@ -392,7 +649,7 @@ func (rtfObj *RtfStructure) parseControlWord() {
 		// check if the parameter is negative (-digits)
 		bp, err = rtfObj.reader.Peek(1)
 		if (err == nil && string(bp[0]) == "-") {
-			b, err = rtfObj.reader.ReadByte()
+			b, err = rtfObj.readByte()
 			//fmt.Println("Read extract negative: ",string(b))
 			if err == nil {
 				parameterBuffer.WriteString("-")
@@ -406,7 +663,7 @@ func (rtfObj *RtfStructure) parseControlWord() {
 				break;
 			}
 
-			b, err = rtfObj.reader.ReadByte()
+			b, err = rtfObj.readByte()
 
 			//fmt.Println("Read extract parameter: ",string(b))
 
@@ -426,13 +683,18 @@ func (rtfObj *RtfStructure) parseControlWord() {
 			 *  depite the above explanation , I remove the space from documente and control word
 			 */
 
-			rtfObj.reader.ReadByte()
+			rtfObj.readByte()
 		}
 
 
 		controlWord := wordBuffer.String()
 		controlWordParameter := parameterBuffer.String()
 
+		if controlWord == "bin" {
+			rtfObj.parseBinary(controlWordParameter, position)
+			return
+		}
+
 
 		if len(controlWordParameter) > 0 {
 			parameter, err = strconv.Atoi(controlWordParameter)
@@ -488,7 +750,7 @@ func (rtfObj *RtfStructure) parseControlWord() {
                 }
 
                 // read an entire char (if is multibyte)
-                br,_, err = rtfObj.reader.ReadRune()
+                br, err = rtfObj.readRune()
                 if err != nil {
                 	break
                 }
@@ -501,15 +763,27 @@ func (rtfObj *RtfStructure) parseControlWord() {
                 // If the replacement character is encoded as hexadecimal value \'HH then jump over it
                 if (string(br) == "\\" && string(bp[0]) == "'") {
                     // move pointer after 4 chars \'HH
-                    _, err = rtfObj.reader.ReadByte()
+                    _, err = rtfObj.readByte()
+                    if err != nil {
+                    	break
+                    }
+                    _, err = rtfObj.readByte()
                     if err != nil {
                     	break
                     }
-                    _, err = rtfObj.reader.ReadByte()
+                    _, err = rtfObj.readByte()
                     if err != nil {
                     	break
                     }
-                    _, err = rtfObj.reader.ReadByte()
+                } else if (string(br) == "\\" && ByteIsAsciiLetter(bp[0])) {
+                    // a control word (and the data of \binN) is a single replacement character
+                    rtfObj.skipControlWord(rtfObj.controlPosition())
+                    if rtfObj.err != nil {
+                    	break
+                    }
+                } else if (string(br) == "\\") {
+                    // a control symbol (\{, \}, \\, \~, etc) is a single replacement character
+                    _, err = rtfObj.readByte()
                     if err != nil {
                     	break
                     }
@@ -523,9 +797,145 @@ func (rtfObj *RtfStructure) parseControlWord() {
             }
         }
 
-		obj := &rtfControlWord{word: controlWord, parameter: controlWordParameter}
+		rtfObj.emit(RtfToken{Type: RtfTokenControlWord, Name: controlWord, Parameter: controlWordParameter})
+}
+
+/**
+ * \binN: the N bytes that follow the control word (after the space delimiter) are binary data, not RTF text;
+ * the data may contain any byte, including { } and \
+ */
+func (rtfObj *RtfStructure) parseBinary(parameter string, position *ParseError) {
+	size, err := strconv.Atoi(parameter)
+	if err != nil || size < 0 {
+		// \bin without a valid parameter has no data
+		size = 0
+	}
+
+	if rtfObj.tokenHandler == nil {
+		data, err := rtfObj.readBinary(size)
+		if err != nil {
+			rtfObj.err = binaryError(err, position)
+			return
+		}
+		rtfObj.emit(RtfToken{Type: RtfTokenBinary, Name: "bin", Parameter: strconv.Itoa(len(data)), Content: data})
+		return
+	}
+
+	// in stream mode the data is sent in chunks, each one a binary token with its own size
+	for {
+		chunkSize := size
+		if chunkSize > rtfStreamTextChunkSize {
+			chunkSize = rtfStreamTextChunkSize
+		}
+
+		data, err := rtfObj.readBinary(chunkSize)
+		if err != nil {
+			rtfObj.err = binaryError(err, position)
+			return
+		}
+		rtfObj.emit(RtfToken{Type: RtfTokenBinary, Name: "bin", Parameter: strconv.Itoa(len(data)), Content: data})
+
+		size -= chunkSize
+		if size <= 0 || rtfObj.err != nil {
+			return
+		}
+	}
+}
+
+/**
+ * the position of the \ that starts the control word being read (the \ was just read)
+ */
+func (rtfObj *RtfStructure) controlPosition() (*ParseError) {
+	position := rtfObj.parseError(nil)
+	position.Offset--
+	position.Column--
+	return position
+}
 
-		rtfObj.currentGroup.addChild(obj)
+/**
+ * the data of \binN can not be read: the document ends before the N bytes (or the read fails)
+ * the error is reported at the position of the \bin control word
+ */
+func binaryError(err error, position *ParseError) (error) {
+	if _, ok := err.(*ParseError); ok {
+		// eg: the document exceeds the maximum size
+		return err
+	}
+
+	if err == io.EOF {
+		err = ErrTruncatedBinary
+	}
+	position.Err = err
+	return position
+}
+
+/**
+ * read size bytes; the buffer grows while the data is read, so a wrong size does not allocate the memory in advance
+ * if the document ends before the data, the read bytes are returned with io.EOF
+ */
+func (rtfObj *RtfStructure) readBinary(size int) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+
+	for i := 0; i < size; i++ {
+		b, err := rtfObj.readByte()
+		if err != nil {
+			return buffer.Bytes(), err
+		}
+		buffer.WriteByte(b)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+/**
+ * read and drop size bytes, without keeping the data in memory
+ */
+func (rtfObj *RtfStructure) skipBinary(size int) (error) {
+	for i := 0; i < size; i++ {
+		if _, err := rtfObj.readByte(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/**
+ * skip a control word that replaces a \uN char: \letters[-digits] and the space delimiter; the data of \binN is skipped too
+ */
+func (rtfObj *RtfStructure) skipControlWord(position *ParseError) {
+	wordBuffer := &bytes.Buffer{}
+	parameterBuffer := &bytes.Buffer{}
+
+	for {
+		bp, err := rtfObj.reader.Peek(1)
+		if err != nil || !ByteIsAsciiLetter(bp[0]) {
+			break
+		}
+		b, _ := rtfObj.readByte()
+		wordBuffer.WriteByte(b)
+	}
+
+	for {
+		bp, err := rtfObj.reader.Peek(1)
+		if err != nil || !(ByteIsDigit(bp[0]) || (bp[0] == '-' && parameterBuffer.Len() == 0)) {
+			break
+		}
+		b, _ := rtfObj.readByte()
+		parameterBuffer.WriteByte(b)
+	}
+
+	if bp, err := rtfObj.reader.Peek(1); err == nil && bp[0] == ' ' {
+		rtfObj.readByte()
+	}
+
+	if wordBuffer.String() == "bin" {
+		if size, err := strconv.Atoi(parameterBuffer.String()); err == nil && size > 0 {
+			if err := rtfObj.skipBinary(size); err != nil {
+				rtfObj.err = binaryError(err, position)
+			}
+		}
+	}
 }
 
 func (rtfObj *RtfStructure) Dump() {
@@ -539,11 +949,15 @@ func (rtfObj *RtfStructure) Dump() {
  * @return {[type]}        [description]
  */
 func (rtfObj *RtfStructure) IsValid() (bool) {
+	if rtfObj.Root == nil {
+		return false
+	}
+
 	children := rtfObj.Root.GetChildren()
 	if len(children) > 0 {
 		switch children[0].(type) {
-		case *rtfControlWord:
-			if children[0].(*rtfControlWord).GetWord() == "rtf" && children[0].(*rtfControlWord).GetParameter()=="1" {
+		case *ControlWord:
+			if children[0].(*ControlWord).GetWord() == "rtf" && children[0].(*ControlWord).GetParameter()=="1" {
 				return true
 			}
 		}
@@ -560,44 +974,41 @@ func (rtfObj *RtfStructure) IsValid() (bool) {
  */
 
 func (rtfObj *RtfStructure) IsHtmlEncapsulated() (bool) {
-	idx := 0;
-	children := rtfObj.Root.GetChildren()
-	for {
-		for _, item := range children {
-			switch item.(type) {
-			case *rtfControlWord:
-				if item.(*rtfControlWord).GetWord() == "fromhtml" {
-					return true
-				}
-			}
-			idx++
-		}
-
-		if (idx > 10) {
-			break;
-		}
-	}
-	return false
+	return rtfObj.DetectEncapsulation() == RtfEncapsulationHtml
 }
 
 
 func (rtfObj *RtfStructure) IsTextEncapsulated() (bool) {
-	idx := 0;
-	children := rtfObj.Root.GetChildren()
-	for {
-		for _, item := range children {
-			switch item.(type) {
-			case *rtfControlWord:
-				if item.(*rtfControlWord).GetWord() == "fromtext" {
-					return true
+	return rtfObj.DetectEncapsulation() == RtfEncapsulationText
+}
+
+/**
+ * detect the format the RTF document was produced from: html (\fromhtml), text (\fromtext) or a native RTF document
+ * only the first 10 tokens (begin group marks and control words) are inspected
+ */
+func (rtfObj *RtfStructure) DetectEncapsulation() (RtfEncapsulation) {
+	if rtfObj.Root == nil {
+		return RtfEncapsulationNone
+	}
+
+	var tokens []RtfToken
+
+	var collect func(item Element) bool
+	collect = func(item Element) bool {
+		switch obj := item.(type) {
+		case *Group:
+			tokens = append(tokens, RtfToken{Type: RtfTokenGroupStart})
+			for _, child := range obj.GetChildren() {
+				if !collect(child) {
+					return false
 				}
 			}
-			idx++
-		}
-
-		if (idx > 10) {
-			break;
+		case *ControlWord:
+			tokens = append(tokens, RtfToken{Type: RtfTokenControlWord, Name: obj.GetWord(), Parameter: obj.GetParameter()})
 		}
+		return len(tokens) < rtfEncapsulationInspectedTokens
 	}
-	return false
-}
\ No newline at end of file
+	collect(rtfObj.Root)
+
+	return detectTokensEncapsulation(tokens)
+}
@@ -0,0 +1,164 @@
+package rtfconverter
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestParseStreamBinaryChunks(t *testing.T) {
+	size := 2*rtfStreamTextChunkSize + 10
+	data := bytes.Repeat([]byte{'{', '}', '\\', 0x00}, size/4+1)[:size]
+
+	doc := &bytes.Buffer{}
+	doc.WriteString("{\\rtf1{\\pict\\bin" + strconv.Itoa(size) + " ")
+	doc.Write(data)
+	doc.WriteString("}}")
+
+	var (
+		rtfObj   RtfStructure
+		streamed []byte
+		chunks   int
+	)
+	err := rtfObj.ParseStream(bytes.NewReader(doc.Bytes()), func(token RtfToken) error {
+		if token.Type != RtfTokenBinary {
+			return nil
+		}
+		if len(token.Content) > rtfStreamTextChunkSize {
+			t.Fatalf("binary token of %d bytes", len(token.Content))
+		}
+		if token.Parameter != strconv.Itoa(len(token.Content)) {
+			t.Fatalf("binary token parameter %s for %d bytes", token.Parameter, len(token.Content))
+		}
+		streamed = append(streamed, token.Content...)
+		chunks++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("stream parse failed: %v", err)
+	}
+	if chunks != 3 || !bytes.Equal(streamed, data) {
+		t.Fatalf("got %d bytes in %d chunks, expected %d bytes in 3 chunks", len(streamed), chunks, len(data))
+	}
+
+	// the tree keeps the data in a single node
+	var tree RtfStructure
+	if err := tree.ParseBytes(doc.Bytes()); err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	binaries := 0
+	tree.Root.Walk(func(item Element) error {
+		if obj, ok := item.(*Binary); ok {
+			binaries++
+			if !bytes.Equal(obj.GetData(), data) {
+				t.Fatalf("binary node has %d bytes, expected %d", len(obj.GetData()), len(data))
+			}
+		}
+		return nil
+	})
+	if binaries != 1 {
+		t.Fatalf("got %d binary nodes", binaries)
+	}
+}
+
+func TestParseTruncatedBinary(t *testing.T) {
+	doc := []byte("{\\rtf1\n{\\pict\\bin1000000 abc")
+
+	var rtfObj RtfStructure
+	err := rtfObj.ParseReader(bytes.NewReader(doc))
+	if !errors.Is(err, ErrTruncatedBinary) {
+		t.Fatalf("expected %v, got %v", ErrTruncatedBinary, err)
+	}
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected a ParseError, got %T", err)
+	}
+	if parseErr.Offset != 13 || parseErr.Line != 2 || parseErr.Column != 7 {
+		t.Fatalf("error at offset %d, line %d, column %d", parseErr.Offset, parseErr.Line, parseErr.Column)
+	}
+
+	// the stream mode reports the same error
+	var streamObj RtfStructure
+	err = streamObj.ParseStream(bytes.NewReader(doc), func(token RtfToken) error { return nil })
+	if !errors.Is(err, ErrTruncatedBinary) {
+		t.Fatalf("expected %v, got %v", ErrTruncatedBinary, err)
+	}
+
+	// \binN skipped as the replacement char of \uN
+	var skipObj RtfStructure
+	err = skipObj.ParseBytes([]byte("{\\rtf1 \\u8364\\bin10 abc"))
+	if !errors.Is(err, ErrTruncatedBinary) {
+		t.Fatalf("expected %v, got %v", ErrTruncatedBinary, err)
+	}
+}
+
+func TestParseTwice(t *testing.T) {
+	var rtfObj RtfStructure
+
+	if err := rtfObj.ParseBytes([]byte("{\\rtf1\\uc2 first}")); err != nil {
+		t.Fatalf("first parse failed: %v", err)
+	}
+	if err := rtfObj.ParseBytes([]byte("{\\rtf1 \\u8364?second}")); err != nil {
+		t.Fatalf("second parse failed: %v", err)
+	}
+
+	var texts []string
+	rtfObj.Walk(func(item Element) error {
+		if obj, ok := item.(*Text); ok {
+			texts = append(texts, string(obj.GetContent()))
+		}
+		return nil
+	})
+	if len(texts) != 1 || texts[0] != "second" {
+		t.Fatalf("got texts %q, expected only the second document", texts)
+	}
+
+	// an unbalanced document does not break the next parse
+	if err := rtfObj.ParseBytes([]byte("{\\rtf1{\\b unclosed}")); !errors.Is(err, ErrUnbalancedGroups) {
+		t.Fatalf("expected %v, got %v", ErrUnbalancedGroups, err)
+	}
+	if err := rtfObj.ParseBytes([]byte("{\\rtf1 third}")); err != nil {
+		t.Fatalf("parse after an unbalanced document failed: %v", err)
+	}
+}
+
+func TestWriteStable(t *testing.T) {
+	tests := []struct {
+		name string
+		rtf  string
+	}{
+		{"plain", "{\\rtf1\\ansi\\deff0 {\\fonttbl{\\f0 Arial;}}\\pard\\b bold\\b0  text\\par}"},
+		{"escapes", "{\\rtf1 a\\{b\\}c\\\\d \\~\\-\\_\\'e9t\\'e8\\par}"},
+		{"control symbol after word", "{\\rtf1\\tab\\'e9\\tab\\{x\\}}"},
+		{"escaped line break", "{\\rtf1 line\\\r\nnext}"},
+		{"binary", "{\\rtf1{\\*\\blipuid x}{\\pict\\bin5 a{}\\b}after}"},
+		{"unicode", "{\\rtf1 \\u8364?x \\uc2\\u-3913\\'e8\\'8d y{\\uc0\\u20013 z}\\u8364? w}"},
+		{"unicode skipped control word", "{\\rtf1\\uc1\\u8364\\tab next}"},
+		{"non ascii text", "{\\rtf1\\ansi\\ansicpg1252 a\\tab\xe8\x8d\xa4? b}"},
+		{"leading spaces", "{\\rtf1\\b   spaces\\b0 ?mark}"},
+	}
+
+	parseWrite := func(rtf []byte) []byte {
+		var rtfObj RtfStructure
+		if err := rtfObj.ParseBytes(rtf); err != nil {
+			t.Fatalf("parse failed: %v\n%s", err, rtf)
+		}
+		result, err := rtfObj.Bytes()
+		if err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		return result
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			first := parseWrite([]byte(test.rtf))
+			second := parseWrite(first)
+			if !bytes.Equal(first, second) {
+				t.Fatalf("the second write differs:\n%s\n%s", first, second)
+			}
+		})
+	}
+}
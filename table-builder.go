@@ -0,0 +1,359 @@
+/**
+ * rebuild the tables of a native RTF document from the table control words: the row definition (\trowd, the cell
+ * properties and \cellxN), the cell contents (ended by \cell) and the end of the row (\row)
+ * there is no table group in RTF; a table ends at the first paragraph that is not in the table (without \intbl)
+ */
+
+package rtfconverter
+
+import (
+	"sort"
+	"strings"
+)
+
+/**
+ * the maximum number of cells of a row and of distinct cell boundaries of a table (the limit of Word is 63 columns);
+ * the grid of a table is never larger, whatever the document defines
+ */
+const rtfTableMaxColumns = 63
+
+/**
+ * the sides of the cell borders; the row borders have also the inside horizontal and inside vertical borders
+ */
+const (
+	rtfBorderTop = iota
+	rtfBorderLeft
+	rtfBorderBottom
+	rtfBorderRight
+	rtfBorderHorizontal
+	rtfBorderVertical
+)
+
+var rtfBorderStyles map[string]string = map[string]string{
+	"brdrs":      "solid",
+	"brdrth":     "solid",
+	"brdrsh":     "solid",
+	"brdrhair":   "solid",
+	"brdrdb":     "double",
+	"brdrtriple": "double",
+	"brdrdot":    "dotted",
+	"brdrdash":   "dashed",
+	"brdrdashsm": "dashed",
+	"brdrdashd":  "dashed",
+	"brdrdashdd": "dashed",
+	"brdrinset":  "inset",
+	"brdroutset": "outset",
+	"brdrnone":   "",
+	"brdrnil":    "",
+}
+
+type rtfTableBorder struct {
+	// css border style; empty if there is no border
+	style string
+
+	// width in twips
+	width int
+
+	// index from color table; 0 is the auto color
+	color int
+}
+
+/**
+ * the properties of a cell, defined before \cellxN
+ */
+type rtfTableCellDef struct {
+	// the right boundary of the cell in twips
+	right int
+
+	// horizontally merged cells: \clmgf is the first cell, \clmrg is merged with the previous cell
+	mergeFirst bool
+	merged     bool
+
+	// vertically merged cells: \clvmgf is the first cell, \clvmrg is merged with the cell above
+	vMergeFirst bool
+	vMerged     bool
+
+	borders [4]rtfTableBorder
+
+	// index from color table; 0 if the cell is not shaded
+	background int
+
+	// css vertical-align value
+	verticalAlign string
+}
+
+type rtfTableRow struct {
+	defs     []rtfTableCellDef
+	borders  [6]rtfTableBorder
+	contents []string
+}
+
+/**
+ * a cell placed in the grid of the table
+ */
+type rtfTableCell struct {
+	def     rtfTableCellDef
+	content string
+
+	// the first column of the cell and the number of columns and rows covered by the cell
+	column  int
+	colspan int
+	rowspan int
+
+	// the cell is merged in another cell (origin) and is not rendered
+	hidden bool
+	origin *rtfTableCell
+}
+
+type rtfTable struct {
+	rows        [][]*rtfTableCell
+	columnCount int
+}
+
+type rtfTableBuilder struct {
+	// the definition of the current row
+	defs    []rtfTableCellDef
+	cell    rtfTableCellDef
+	borders [6]rtfTableBorder
+
+	// the border changed by the \brdrXXX words
+	border *rtfTableBorder
+
+	// the contents of the ended cells of the current row
+	contents []string
+
+	rows []rtfTableRow
+}
+
+/**
+ * apply a table definition control word; return false if the word is not a table definition word
+ */
+func (b *rtfTableBuilder) parseControlWord(item *ControlWord) bool {
+	word := item.GetWord()
+
+	if style, ok := rtfBorderStyles[word]; ok {
+		if b.border != nil {
+			b.border.style = style
+		}
+		return true
+	}
+
+	switch word {
+	case "trowd":
+		b.defs = nil
+		b.cell = rtfTableCellDef{}
+		b.borders = [6]rtfTableBorder{}
+		b.border = nil
+	case "cellx":
+		b.cell.right = item.GetIntParameter()
+		b.defs = append(b.defs, b.cell)
+		b.cell = rtfTableCellDef{}
+		b.border = nil
+	case "clmgf":
+		b.cell.mergeFirst = true
+	case "clmrg":
+		b.cell.merged = true
+	case "clvmgf":
+		b.cell.vMergeFirst = true
+	case "clvmrg":
+		b.cell.vMerged = true
+	case "clcbpat":
+		b.cell.background = item.GetIntParameter()
+	case "clvertalt":
+		b.cell.verticalAlign = "top"
+	case "clvertalc":
+		b.cell.verticalAlign = "middle"
+	case "clvertalb":
+		b.cell.verticalAlign = "bottom"
+	case "clbrdrt":
+		b.border = &b.cell.borders[rtfBorderTop]
+	case "clbrdrl":
+		b.border = &b.cell.borders[rtfBorderLeft]
+	case "clbrdrb":
+		b.border = &b.cell.borders[rtfBorderBottom]
+	case "clbrdrr":
+		b.border = &b.cell.borders[rtfBorderRight]
+	case "trbrdrt":
+		b.border = &b.borders[rtfBorderTop]
+	case "trbrdrl":
+		b.border = &b.borders[rtfBorderLeft]
+	case "trbrdrb":
+		b.border = &b.borders[rtfBorderBottom]
+	case "trbrdrr":
+		b.border = &b.borders[rtfBorderRight]
+	case "trbrdrh":
+		b.border = &b.borders[rtfBorderHorizontal]
+	case "trbrdrv":
+		b.border = &b.borders[rtfBorderVertical]
+	case "brdrt", "brdrl", "brdrb", "brdrr", "box", "brdrbar", "brdrbtw":
+		// the paragraph borders are not table borders
+		b.border = nil
+	case "brdrw":
+		if b.border != nil {
+			b.border.width = item.GetIntParameter()
+		}
+	case "brdrcf":
+		if b.border != nil {
+			b.border.color = item.GetIntParameter()
+		}
+	default:
+		return false
+	}
+
+	return true
+}
+
+func (b *rtfTableBuilder) endCell(content string) {
+	b.contents = append(b.contents, content)
+}
+
+/**
+ * the row takes the last definition; Word may repeat the row definition before \row
+ */
+func (b *rtfTableBuilder) endRow() {
+	b.rows = append(b.rows, rtfTableRow{
+		defs:     append([]rtfTableCellDef(nil), b.defs...),
+		borders:  b.borders,
+		contents: b.contents,
+	})
+	b.contents = nil
+}
+
+func (b *rtfTableBuilder) isEmpty() bool {
+	return len(b.rows) == 0 && len(b.contents) == 0
+}
+
+/**
+ * place the cells of the ended rows in the grid of the table and reset the builder
+ * the columns of the grid are the distinct right boundaries of all the cells, so a cell spans the columns between its
+ * left and its right boundary; the merged cells are hidden and the span of the first cell is increased
+ *
+ * the cells after rtfTableMaxColumns in a row are joined in the last cell; if the table has more than rtfTableMaxColumns
+ * distinct boundaries, the boundaries are ignored and each cell takes a single column
+ */
+func (b *rtfTableBuilder) table() *rtfTable {
+	if len(b.contents) > 0 {
+		// the last row was not ended with \row
+		b.endRow()
+	}
+
+	var boundaries []int
+	seen := map[int]bool{}
+	for _, row := range b.rows {
+		for _, def := range row.defs {
+			if !seen[def.right] {
+				seen[def.right] = true
+				boundaries = append(boundaries, def.right)
+			}
+		}
+	}
+	sort.Ints(boundaries)
+	useBoundaries := len(boundaries) <= rtfTableMaxColumns
+
+	t := &rtfTable{}
+	for ri, row := range b.rows {
+		var (
+			cells    []*rtfTableCell
+			previous *rtfTableCell
+		)
+
+		contents := row.contents
+		if len(contents) > rtfTableMaxColumns {
+			last := strings.Join(contents[rtfTableMaxColumns-1:], " ")
+			contents = append(contents[:rtfTableMaxColumns-1:rtfTableMaxColumns-1], last)
+		}
+
+		column := 0
+		for i, content := range contents {
+			cell := &rtfTableCell{content: content, column: column, colspan: 1, rowspan: 1}
+
+			if i < len(row.defs) {
+				cell.def = row.defs[i]
+				cell.def.borders = rowCellBorders(row, i, len(contents))
+
+				if useBoundaries {
+					if end := sort.SearchInts(boundaries, cell.def.right); end >= column {
+						cell.colspan = end - column + 1
+					}
+				}
+			}
+			column += cell.colspan
+
+			if cell.def.merged && previous != nil {
+				previous.colspan += cell.colspan
+				cell.hidden = true
+				cell.origin = previous
+			} else if cell.def.vMerged && ri > 0 {
+				if above := t.cellAt(ri-1, cell.column); above != nil {
+					origin := above
+					if above.origin != nil {
+						origin = above.origin
+					}
+					origin.rowspan++
+					cell.hidden = true
+					cell.origin = origin
+				}
+			}
+
+			if !cell.hidden {
+				previous = cell
+			}
+			cells = append(cells, cell)
+		}
+
+		if column > t.columnCount {
+			t.columnCount = column
+		}
+		t.rows = append(t.rows, cells)
+	}
+
+	b.rows = nil
+	b.contents = nil
+
+	return t
+}
+
+/**
+ * the cell that starts at the column, in the row
+ */
+func (t *rtfTable) cellAt(row int, column int) *rtfTableCell {
+	for _, cell := range t.rows[row] {
+		if cell.column == column {
+			return cell
+		}
+	}
+	return nil
+}
+
+/**
+ * the borders of the cell; the sides without a cell border take the row border
+ */
+func rowCellBorders(row rtfTableRow, idx int, count int) [4]rtfTableBorder {
+	borders := row.defs[idx].borders
+
+	fallback := [4]rtfTableBorder{
+		row.borders[rtfBorderHorizontal],
+		row.borders[rtfBorderVertical],
+		row.borders[rtfBorderHorizontal],
+		row.borders[rtfBorderVertical],
+	}
+	if row.borders[rtfBorderTop].style != "" {
+		fallback[rtfBorderTop] = row.borders[rtfBorderTop]
+	}
+	if row.borders[rtfBorderBottom].style != "" {
+		fallback[rtfBorderBottom] = row.borders[rtfBorderBottom]
+	}
+	if idx == 0 && row.borders[rtfBorderLeft].style != "" {
+		fallback[rtfBorderLeft] = row.borders[rtfBorderLeft]
+	}
+	if idx == count-1 && row.borders[rtfBorderRight].style != "" {
+		fallback[rtfBorderRight] = row.borders[rtfBorderRight]
+	}
+
+	for side := range borders {
+		if borders[side].style == "" {
+			borders[side] = fallback[side]
+		}
+	}
+	return borders
+}
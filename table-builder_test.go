@@ -0,0 +1,145 @@
+package rtfconverter
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var tableWordPattern = regexp.MustCompile(`^([a-z]+)(-?\d*)$`)
+
+/**
+ * apply the words (eg: "trowd clvmgf cellx1000") to the builder; "|text" ends a cell and "row" ends the row
+ */
+func buildTable(t *testing.T, b *rtfTableBuilder, words string) {
+	t.Helper()
+
+	for _, word := range strings.Fields(words) {
+		switch {
+		case strings.HasPrefix(word, "|"):
+			b.endCell(word[1:])
+		case word == "row":
+			b.endRow()
+		default:
+			m := tableWordPattern.FindStringSubmatch(word)
+			if m == nil || !b.parseControlWord(NewControlWord(m[1], m[2])) {
+				t.Fatalf("not a table word: %s", word)
+			}
+		}
+	}
+}
+
+type tableCellSpan struct {
+	column  int
+	colspan int
+	rowspan int
+	hidden  bool
+}
+
+func checkTableCells(t *testing.T, table *rtfTable, expected [][]tableCellSpan) {
+	t.Helper()
+
+	if len(table.rows) != len(expected) {
+		t.Fatalf("got %d rows, expected %d", len(table.rows), len(expected))
+	}
+	for ri, row := range table.rows {
+		if len(row) != len(expected[ri]) {
+			t.Fatalf("row %d has %d cells, expected %d", ri, len(row), len(expected[ri]))
+		}
+		for ci, cell := range row {
+			got := tableCellSpan{cell.column, cell.colspan, cell.rowspan, cell.hidden}
+			if got != expected[ri][ci] {
+				t.Errorf("cell %d,%d is %+v, expected %+v", ri, ci, got, expected[ri][ci])
+			}
+		}
+	}
+}
+
+func TestTableBuilderBoundaries(t *testing.T) {
+	b := rtfTableBuilder{}
+	buildTable(t, &b, "trowd cellx1000 cellx3000 |a |b row")
+	buildTable(t, &b, "trowd cellx1000 cellx2000 cellx3000 |c |d |e row")
+
+	table := b.table()
+	if table.columnCount != 3 {
+		t.Fatalf("got %d columns, expected 3", table.columnCount)
+	}
+	checkTableCells(t, table, [][]tableCellSpan{
+		{{0, 1, 1, false}, {1, 2, 1, false}},
+		{{0, 1, 1, false}, {1, 1, 1, false}, {2, 1, 1, false}},
+	})
+
+	if !b.isEmpty() {
+		t.Fatalf("the builder is not reset")
+	}
+}
+
+func TestTableBuilderMergedCells(t *testing.T) {
+	b := rtfTableBuilder{}
+	buildTable(t, &b, "trowd clmgf cellx1000 clmrg cellx2000 cellx3000 |a |x |b row")
+	buildTable(t, &b, "trowd clvmgf cellx1000 cellx2000 cellx3000 |c |d |e row")
+	buildTable(t, &b, "trowd clvmrg cellx1000 cellx2000 cellx3000 | |f |g row")
+	buildTable(t, &b, "trowd clvmrg cellx1000 cellx2000 cellx3000 | |h |i")
+
+	table := b.table()
+	checkTableCells(t, table, [][]tableCellSpan{
+		{{0, 2, 1, false}, {1, 1, 1, true}, {2, 1, 1, false}},
+		{{0, 1, 3, false}, {1, 1, 1, false}, {2, 1, 1, false}},
+		{{0, 1, 1, true}, {1, 1, 1, false}, {2, 1, 1, false}},
+		{{0, 1, 1, true}, {1, 1, 1, false}, {2, 1, 1, false}},
+	})
+
+	if origin := table.rows[3][0].origin; origin != table.rows[1][0] {
+		t.Fatalf("the vertically merged cell is not merged in the first cell")
+	}
+
+	expected := "| a   |     | b   |\r\n" +
+		"|-----|-----|-----|\r\n" +
+		"| c   | d   | e   |\r\n" +
+		"|     | f   | g   |\r\n" +
+		"|     | h   | i   |\r\n"
+	if got := textTable(table); got != expected {
+		t.Fatalf("unexpected text grid:\n%s", got)
+	}
+}
+
+func TestTableBuilderColumnLimit(t *testing.T) {
+	b := rtfTableBuilder{}
+
+	// distinct boundaries in each row
+	for i := 0; i < 1000; i++ {
+		right := i * 3
+		buildTable(t, &b, "trowd cellx"+strconv.Itoa(right+1)+" cellx"+strconv.Itoa(right+2)+" cellx"+strconv.Itoa(right+3)+" |a |b |c row")
+	}
+
+	table := b.table()
+	if table.columnCount != 3 {
+		t.Fatalf("got %d columns, expected 3", table.columnCount)
+	}
+	for _, row := range table.rows {
+		for ci, cell := range row {
+			if cell.column != ci || cell.colspan != 1 {
+				t.Fatalf("cell %d placed at column %d with colspan %d", ci, cell.column, cell.colspan)
+			}
+		}
+	}
+
+	// too many cells in a row
+	words := "trowd"
+	for i := 1; i <= 100; i++ {
+		words += " cellx" + strconv.Itoa(i*100)
+	}
+	for i := 1; i <= 100; i++ {
+		words += " |" + strconv.Itoa(i)
+	}
+	buildTable(t, &b, words+" row")
+
+	table = b.table()
+	if table.columnCount != rtfTableMaxColumns || len(table.rows[0]) != rtfTableMaxColumns {
+		t.Fatalf("got %d columns and %d cells, expected %d", table.columnCount, len(table.rows[0]), rtfTableMaxColumns)
+	}
+	if last := table.rows[0][rtfTableMaxColumns-1].content; !strings.HasPrefix(last, "63 64 ") || !strings.HasSuffix(last, " 100") {
+		t.Fatalf("the cells after the limit are not joined in the last cell: %q", last)
+	}
+}
@@ -1,34 +1,89 @@
 package rtfconverter
 
 import (
-//	"fmt"
+	"bytes"
+	"fmt"
+	"io"
 )
 
 type rtfTextInterpreter struct {
 	content []byte
+
+	// the converter options
+	options rtfOptions
 }
 
 func (p *rtfTextInterpreter) Parse(rtfObj RtfStructure) ([]byte, error) {
+	buffer := bytes.Buffer{}
+
+	if err := p.ParseTo(&buffer, rtfObj); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (p *rtfTextInterpreter) ParseTo(w io.Writer, rtfObj RtfStructure) (error) {
+	if !rtfObj.IsValid() {
+		return ErrNotRTF
+	}
+
+	parser, err := p.selectParser(rtfObj.DetectEncapsulation())
+	if err != nil {
+		return err
+	}
+
+	output := p.options.outputWriter(w, false)
+	if err := parseTree(parser, rtfObj, output); err != nil {
+		return err
+	}
+	return output.Close()
+}
+
+/**
+ * convert the document while it is read from the reader, without building the RTF tree
+ */
+func (p *rtfTextInterpreter) ParseReader(reader io.Reader) ([]byte, error) {
+	buffer := bytes.Buffer{}
+
+	if err := p.ParseReaderTo(&buffer, reader); err != nil {
+		return nil, err
+	}
 
-	var (
-		result []byte
-		err error
-	)
-
-	/**
-	 *	The de-encapsulating RTF reader SHOULD<14> inspect no more than the first 10 RTF tokens
-	 *	(that is, begin group marks and control words) in the input RTF document, in sequence, starting from the beginning of the RTF document.
-	 *	If one of the control words is the FROMHTML control word, the de-encapsulating RTF reader SHOULD conclude that the RTF document contains
-	 *	an encapsulated HTML document and stop further inspection. If one of the control words is the FROMTEXT control word, the de-encapsulating
-	 *	RTF reader SHOULD conclude that the RTF document was produced from a plain text document and stop further inspection.
-	 */
-	if (rtfObj.IsTextEncapsulated()) {
-		// the RTF was generated from a html file
-		parser := rtfTextEncapsulatedInterpreter{}
-
-		//rtfObj.Dump()
-		result, err =  parser.Parse(rtfObj)
+	return buffer.Bytes(), nil
+}
+
+/**
+ * convert the document while it is read from the reader, and write the result while it is converted
+ */
+func (p *rtfTextInterpreter) ParseReaderTo(w io.Writer, reader io.Reader) (error) {
+	output := p.options.outputWriter(w, false)
+	if err := parseTokenStream(reader, output, p.options, p.selectParser); err != nil {
+		return err
 	}
-	return result, err
+	return output.Close()
 }
 
+/**
+ *	The de-encapsulating RTF reader SHOULD<14> inspect no more than the first 10 RTF tokens
+ *	(that is, begin group marks and control words) in the input RTF document, in sequence, starting from the beginning of the RTF document.
+ *	If one of the control words is the FROMHTML control word, the de-encapsulating RTF reader SHOULD conclude that the RTF document contains
+ *	an encapsulated HTML document and stop further inspection. If one of the control words is the FROMTEXT control word, the de-encapsulating
+ *	RTF reader SHOULD conclude that the RTF document was produced from a plain text document and stop further inspection.
+ *
+ * a document that encapsulates html is converted as a native RTF document, except in strict mode
+ */
+func (p *rtfTextInterpreter) selectParser(encapsulation RtfEncapsulation) (rtfStreamVisitor, error) {
+	switch encapsulation {
+	case RtfEncapsulationText:
+		// the RTF was generated from a text file
+		return &rtfTextEncapsulatedInterpreter{options: p.options}, nil
+	case RtfEncapsulationHtml:
+		if p.options.strictEncapsulation {
+			return nil, fmt.Errorf("%w (the document was produced from %s)", ErrUnsupportedEncapsulation, encapsulation)
+		}
+	}
+
+	// extract the document text
+	return &rtfTextNativeInterpreter{options: p.options}, nil
+}
@@ -5,93 +5,117 @@
 package rtfconverter
 
 import (
-	"errors"
+	"bufio"
 	"bytes"
+	"io"
 	"strconv"
-	"encoding/binary"
+	"unicode/utf16"
 )
 
 type rtfTextEncapsulatedInterpreter struct {
-	content 				bytes.Buffer
+	content 				*bufio.Writer
 	insideHtmlTagGroup 		int
 	rtfEncoding 			string
 	defaultFont 			int
 	fontTable  				map[int]*rtfFontTableItem
 	colorTable 				[]rtfColor
-	styleTag		        string
 
-	// keep the initial state when a group is parsed
-	groupsInitialStates				[]rtfState
+	// the converter options
+	options rtfOptions
 
-	// keep the previous state of the parsed group
-	groupPreviousState		rtfState
+	// the formatting of the parsed group: the current font
+	formatting rtfFormattingStack
 
-	// keep the current state of the parsed group
-	groupCurrentState		rtfState
+	// \'HH bytes are collected and decoded together, so the multibyte code pages are decoded correctly
+	pendingBytes []byte
 
-	// count how many style tag are opened
-	styleTagOpened int
-
-	bodyStarted bool
-	bodyStopped bool
+	// the high surrogate of a \uN pair, waiting for the low surrogate
+	pendingSurrogate rune
 }
 
 
 func (p *rtfTextEncapsulatedInterpreter) Parse(rtfObj RtfStructure) ([]byte, error) {
+	buffer := bytes.Buffer{}
 
-	p.content = bytes.Buffer{}
-	p.insideHtmlTagGroup = 0
-
-	if (!rtfObj.IsValid()) {
-		return nil, errors.New("The RTF file is not valid.")
+	if err := parseTree(p, rtfObj, &buffer); err != nil {
+		return nil, err
 	}
 
-	p.parseElement(rtfObj.Root)
+	return buffer.Bytes(), nil
+}
 
-	return p.content.Bytes(), nil
+func (p *rtfTextEncapsulatedInterpreter) startDocument(w io.Writer) {
+	p.content = bufio.NewWriter(w)
+	p.insideHtmlTagGroup = 0
+	p.rtfEncoding = p.options.defaultEncoding
+	p.formatting = newRtfFormattingStack()
+}
+
+func (p *rtfTextEncapsulatedInterpreter) endDocument() error {
+	p.flushText()
+
+	return p.content.Flush()
 }
 
 /**
  * detect the rtf element from structure and decide the parser
- * @param  {[type]} p *rtfTextEncapsulatedInterpreter) parseElement(item rtfElement [description]
+ * @param  {[type]} p *rtfTextEncapsulatedInterpreter) parseElement(item Element [description]
  * @return {[type]}   [description]
  */
-func (p *rtfTextEncapsulatedInterpreter) parseElement(item rtfElement) {
+func (p *rtfTextEncapsulatedInterpreter) parseElement(item Element) {
+	if symbol, ok := item.(*ControlSymbol); !ok || symbol.GetSymbol() != "'" {
+		// the \'HH sequence ended
+		p.flushText()
+	}
+
 	switch item.(type) {
-		case *rtfGroup:
-			p.parseGroup(item.(*rtfGroup));
-		case *rtfControlSymbol:
-			p.parseControlSymbol(item.(*rtfControlSymbol));
-		case *rtfControlWord:
-			p.parseControlWord(item.(*rtfControlWord));
-		case *rtfText:
-			p.parseText(item.(*rtfText));
+		case *Group:
+			p.parseGroup(item.(*Group));
+		case *ControlSymbol:
+			p.parseControlSymbol(item.(*ControlSymbol));
+		case *ControlWord:
+			p.parseControlWord(item.(*ControlWord));
+		case *Text:
+			p.parseText(item.(*Text));
 
 	}
 }
 
 /**
  * parse a rtf group
- * @param  {[type]} p *rtfTextEncapsulatedInterpreter) parseGroup(item *rtfGroup [description]
+ * @param  {[type]} p *rtfTextEncapsulatedInterpreter) parseGroup(item *Group [description]
  * @return {[type]}   [description]
  */
-func (p *rtfTextEncapsulatedInterpreter) parseGroup(item *rtfGroup) {
-	children := item.GetChildren()
+func (p *rtfTextEncapsulatedInterpreter) parseGroup(item *Group) {
+	walkGroup(p, item)
+}
 
+func (p *rtfTextEncapsulatedInterpreter) groupAction(item *Group) rtfGroupAction {
+	if item.IsFontTable() || item.IsColorTable() {
+		return rtfGroupCollect
+	} else if (item.IsStylesheet() || item.IsTrackChanges() || item.IsInfo() || item.IsListtables() || item.IsFilesTable() || item.IsDestination()) {
+		// ignore all these groups
+		return rtfGroupSkip
+	}
+	return rtfGroupWalk
+}
+
+func (p *rtfTextEncapsulatedInterpreter) parseCollectedGroup(item *Group) {
 	if item.IsFontTable() {
 		p.parseFontTableGroup(item)
 	} else if item.IsColorTable() {
 		p.parseColorTableGroup(item)
-	} else if (item.IsStylesheet() || item.IsTrackChanges() || item.IsInfo() || item.IsListtables() || item.IsFilesTable()) {
-		// ignore all these groups
-	} else {
-		if !item.IsDestination() {
-			for _, child := range children {
-				p.parseElement(child)
-			}
-		}
 	}
+}
 
+func (p *rtfTextEncapsulatedInterpreter) startGroup(item *Group) {
+	p.flushText()
+	p.formatting.push()
+}
+
+func (p *rtfTextEncapsulatedInterpreter) endGroup(item *Group) {
+	p.flushText()
+	p.formatting.pop()
 }
 
 
@@ -101,28 +125,28 @@ func (p *rtfTextEncapsulatedInterpreter) parseGroup(item *rtfGroup) {
  *   <fontnum><fontfamily><fcharset>?<fprq>?<panose>?
  *   <nontaggedname>?<fontemb>?<codepage>? <fontname><fontaltname>? ';'
  *
- * @param  {[type]} p *rtfTextEncapsulatedInterpreter) parseFontTableGroup(item *rtfGroup [description]
+ * @param  {[type]} p *rtfTextEncapsulatedInterpreter) parseFontTableGroup(item *Group [description]
  * @return {[type]}   [description]
  */
-func (p *rtfTextEncapsulatedInterpreter) parseFontTableGroup(item *rtfGroup) {
+func (p *rtfTextEncapsulatedInterpreter) parseFontTableGroup(item *Group) {
 	p.fontTable = map[int]*rtfFontTableItem{}
 	for _, child := range item.children {
 		switch child.(type) {
-		case *rtfGroup:
-			if child.(*rtfGroup).IsFontInfo() {
-				p.parseFontInfoGroup(child.(*rtfGroup))
+		case *Group:
+			if child.(*Group).IsFontInfo() {
+				p.parseFontInfoGroup(child.(*Group))
 			}
 		}
 	}
 }
-func (p *rtfTextEncapsulatedInterpreter) parseFontInfoGroup(item *rtfGroup) {
+func (p *rtfTextEncapsulatedInterpreter) parseFontInfoGroup(item *Group) {
 	var (
 		fontIdx int
 	)
 
 	for _, child := range item.GetChildren() {
 		switch cobj := child.(type) {
-			case *rtfControlWord:
+			case *ControlWord:
 				switch cobj.GetWord() {
 					case "f":
 						fontIdx = cobj.GetIntParameter()
@@ -137,18 +161,18 @@ func (p *rtfTextEncapsulatedInterpreter) parseFontInfoGroup(item *rtfGroup) {
 							ftItem.charsetIndex = cobj.GetIntParameter()
 						}
 				}
-			case *rtfText:
+			case *Text:
 				if ftItem, ok := p.fontTable[fontIdx]; ok {
 					ftItem.familyName = string(bytes.TrimRight(cobj.GetContent(), ";"))
 				}
-			case *rtfGroup:
+			case *Group:
 				if (cobj.IsFontAlternative()) {
 					// check alternative font
 					if len(cobj.children)>=3 {
 						switch cobj.children[2].(type) {
-							case *rtfText:
+							case *Text:
 								if ftItem, ok := p.fontTable[fontIdx]; ok {
-									ftItem.familyAlternativeName = string(cobj.children[2].(*rtfText).GetContent())
+									ftItem.familyAlternativeName = string(cobj.children[2].(*Text).GetContent())
 								}
 						}
 
@@ -162,10 +186,10 @@ func (p *rtfTextEncapsulatedInterpreter) parseFontInfoGroup(item *rtfGroup) {
  * extract colors from colortbl tag
  *  {\colortbl;\red0\green0\blue0;}
  * Index 0 of the RTF color table  is the 'auto' color
- * @param  {[type]} p *rtfTextEncapsulatedInterpreter) parseColorTableGroup(item *rtfGroup [description]
+ * @param  {[type]} p *rtfTextEncapsulatedInterpreter) parseColorTableGroup(item *Group [description]
  * @return {[type]}   [description]
  */
-func (p *rtfTextEncapsulatedInterpreter) parseColorTableGroup(item *rtfGroup) {
+func (p *rtfTextEncapsulatedInterpreter) parseColorTableGroup(item *Group) {
 	if (!item.IsColorTable()) {
 		return
 	}
@@ -177,17 +201,17 @@ func (p *rtfTextEncapsulatedInterpreter) parseColorTableGroup(item *rtfGroup) {
 	}
 	for _, child := range item.GetChildren() {
 		switch child.(type) {
-			case *rtfControlWord:
-				switch (child.(*rtfControlWord).GetWord()) {
+			case *ControlWord:
+				switch (child.(*ControlWord).GetWord()) {
 					case "red":
-						color.r = child.(*rtfControlWord).GetIntParameter()
+						color.r = child.(*ControlWord).GetIntParameter()
 					case "green":
-						color.g = child.(*rtfControlWord).GetIntParameter()
+						color.g = child.(*ControlWord).GetIntParameter()
 					case "blue":
-						color.b = child.(*rtfControlWord).GetIntParameter()
+						color.b = child.(*ControlWord).GetIntParameter()
 				}
 
-			case *rtfText:
+			case *Text:
 				// an end of color if marked by a ; text
 				p.colorTable = append(p.colorTable, color)
 
@@ -201,18 +225,16 @@ func (p *rtfTextEncapsulatedInterpreter) parseColorTableGroup(item *rtfGroup) {
 	}
 }
 
-func (p *rtfTextEncapsulatedInterpreter) parseControlSymbol(item *rtfControlSymbol) {
+func (p *rtfTextEncapsulatedInterpreter) parseControlSymbol(item *ControlSymbol) {
 	switch item.GetSymbol() {
 		case "'":
-			// convert the string, reprezenting an hex number to a decimal number
-			v, err := strconv.ParseInt(item.GetParameter(), 16, 16)
+			// convert the string, reprezenting an hex number to a byte
+			v, err := strconv.ParseUint(item.GetParameter(), 16, 8)
 			if (err == nil) {
-				b := make([]byte, 2)
-				binary.LittleEndian.PutUint16(b, uint16(v))
-				b = bytes.TrimRight(b, "\x00")
-				r, _ := ConvertToUtf8(b, p.rtfEncoding);
-				p.content.Write(r)
+				p.pendingBytes = append(p.pendingBytes, byte(v))
 			}
+		case "{", "}", "\\":
+			p.content.WriteString(item.GetSymbol())
 		case "~":
 			p.content.WriteString("-")
 		case "_":
@@ -221,18 +243,11 @@ func (p *rtfTextEncapsulatedInterpreter) parseControlSymbol(item *rtfControlSymb
 }
 
 
-func (p *rtfTextEncapsulatedInterpreter) parseControlWord(item *rtfControlWord) {
+func (p *rtfTextEncapsulatedInterpreter) parseControlWord(item *ControlWord) {
 	// no control words will be added if are inside an htmltag
 	switch  item.GetWord() {
 		case "u" :
-			i, err := strconv.Atoi(item.GetParameter())
-			if err == nil {
-				buff := new(bytes.Buffer)
-         		binary.Write(buff, binary.LittleEndian, i)
-				r, _ := ConvertToUtf8(buff.Bytes(), p.rtfEncoding);
-				p.content.Write(r);
-				//p.content.WriteRune(rune(i))
-			}
+			p.parseUnicode(item)
 			return
 		case "lquote":
 			p.content.WriteString("'")
@@ -256,8 +271,8 @@ func (p *rtfTextEncapsulatedInterpreter) parseControlWord(item *rtfControlWord)
 			p.content.WriteString("--")
 			return
 
-		case "line" : // new line
-			p.content.WriteString("\r\n")
+		case "line" : // new line (a LF without CR in the original text)
+			p.content.WriteString("\n")
 			return
 		case "par" :
 			p.content.WriteString("\r\n")
@@ -275,14 +290,62 @@ func (p *rtfTextEncapsulatedInterpreter) parseControlWord(item *rtfControlWord)
         		p.rtfEncoding, _ = GetEncodingFromCodepage(item.GetParameter())
         	}
         	return
-        case "f", "fs":
-        	// font
+        case "f":
+        	// the text is encoded with the charset of the font
+        	p.formatting.current.parseControlWord(item, rtfStylesheet{})
         	return
      }
 }
 
-func (p *rtfTextEncapsulatedInterpreter) parseText(item *rtfText) {
-	// ignore any text outside an htmlTag group
-	t, _ := ConvertToUtf8(item.GetContent(), p.rtfEncoding)
+func (p *rtfTextEncapsulatedInterpreter) parseText(item *Text) {
+	t, _ := ConvertToUtf8(UnescapeRtfText(item.GetContent()), p.currentEncoding())
+	p.content.Write(t)
+}
+
+/**
+ * the text is encoded with the charset of the current font, or with the document code page
+ */
+func (p *rtfTextEncapsulatedInterpreter) currentEncoding() string {
+	if fItem, ok := p.fontTable[p.formatting.current.font]; ok && fItem.charsetIndex > 2 {
+		if encoding, err := GetEncodingFromCharset(fItem.charsetIndex); err == nil && encoding != "" {
+			return encoding
+		}
+	}
+	return p.rtfEncoding
+}
+
+/**
+ * \uN - the replacement chars (\ucN) are already skipped by the tokenizer
+ * the chars outside the BMP are written as an utf-16 surrogate pair (2 \uN control words)
+ */
+func (p *rtfTextEncapsulatedInterpreter) parseUnicode(item *ControlWord) {
+	r, err := RuneFromUnicodeParameter(item.GetParameter())
+	if err != nil {
+		return
+	}
+
+	if r >= 0xd800 && r < 0xdc00 {
+		p.pendingSurrogate = r
+		return
+	}
+
+	if p.pendingSurrogate != 0 {
+		r = utf16.DecodeRune(p.pendingSurrogate, r)
+		p.pendingSurrogate = 0
+	}
+
+	p.content.WriteString(string(r))
+}
+
+/**
+ * decode the collected \'HH bytes
+ */
+func (p *rtfTextEncapsulatedInterpreter) flushText() {
+	if len(p.pendingBytes) == 0 {
+		return
+	}
+
+	t, _ := ConvertToUtf8(p.pendingBytes, p.currentEncoding())
+	p.pendingBytes = nil
 	p.content.Write(t)
 }
@@ -0,0 +1,16 @@
+package rtfconverter
+
+import (
+	"testing"
+)
+
+func TestTextEncapsulatedLineBreaks(t *testing.T) {
+	rtf := "{\\rtf1\\ansi\\ansicpg1252\\fromtext \\deff0{\\fonttbl{\\f0\\fmodern Courier New;}}\r\n" +
+		"\\pard\\plain\\f0 first\\line second\\par\r\nthird\\tab caf\\'e9\\par\r\n}"
+
+	// \line is a LF without CR in the original text, \par is CR LF
+	expected := "first\nsecond\r\nthird\tcafé\r\n"
+	if got := convertRtf(t, []byte(rtf), "text"); got != expected {
+		t.Fatalf("got %q, expected %q", got, expected)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+	encapsulates a plain text document in a RTF document, the reverse of rtfTextEncapsulatedInterpreter
+	https://docs.microsoft.com/en-us/openspecs/exchange_server_protocols/ms-oxrtfex/906fbb0f-2467-490e-8c3e-bdc31c5e9d35
+*/
+
+package rtfconverter
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+)
+
+type rtfTextEncoder struct {
+	content     *bufio.Writer
+	rtfEncoding string
+	codePage    string
+}
+
+/**
+ * encapsulate the plain text (utf-8) in a RTF document (\fromtext)
+ */
+func EncodeText(text []byte) ([]byte, error) {
+	buffer := bytes.Buffer{}
+
+	if err := EncodeTextTo(&buffer, text); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+/**
+ * encapsulate the plain text (utf-8) in a RTF document (\fromtext) written to w
+ */
+func EncodeTextTo(w io.Writer, text []byte) error {
+	e := rtfTextEncoder{
+		content:     bufio.NewWriter(w),
+		rtfEncoding: "CP1252",
+		codePage:    "1252",
+	}
+
+	return e.encode(string(text))
+}
+
+/**
+ * the line breaks are written as \par (CR LF) or \line (LF), the tabs as \tab; a CR that is not followed by LF is
+ * written as \'0d, so the text is restored exactly by rtfTextEncapsulatedInterpreter
+ */
+func (e *rtfTextEncoder) encode(text string) error {
+	e.content.WriteString("{\\rtf1\\ansi\\ansicpg")
+	e.content.WriteString(e.codePage)
+	e.content.WriteString("\\fromtext \\deff0{\\fonttbl\r\n")
+	e.content.WriteString("{\\f0\\fmodern\\fcharset0 Courier New;}}\r\n")
+	e.content.WriteString("\\uc1\\pard\\plain\\f0\\fs20\r\n")
+
+	for len(text) > 0 {
+		end := strings.IndexAny(text, "\r\n\t")
+		if end < 0 {
+			end = len(text)
+		}
+		e.content.Write(EscapeRtfText(text[:end], e.rtfEncoding))
+		text = text[end:]
+
+		switch {
+		case strings.HasPrefix(text, "\r\n"):
+			e.content.WriteString("\\par\r\n")
+			text = text[2:]
+		case strings.HasPrefix(text, "\n"):
+			e.content.WriteString("\\line\r\n")
+			text = text[1:]
+		case strings.HasPrefix(text, "\r"):
+			e.content.WriteString("\\'0d")
+			text = text[1:]
+		case strings.HasPrefix(text, "\t"):
+			e.content.WriteString("\\tab ")
+			text = text[1:]
+		}
+	}
+
+	e.content.WriteString("}")
+
+	return e.content.Flush()
+}
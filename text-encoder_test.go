@@ -0,0 +1,52 @@
+package rtfconverter
+
+import (
+	"testing"
+)
+
+func convertRtf(t *testing.T, rtf []byte, format string, opts ...Option) string {
+	t.Helper()
+
+	c := NewConverter(opts...)
+	if err := c.SetBytes(rtf); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	result, err := c.Convert(format)
+	if err != nil {
+		t.Fatalf("conversion failed: %v", err)
+	}
+	return string(result)
+}
+
+func TestEncodeTextRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"empty", ""},
+		{"crlf", "first\r\nsecond"},
+		{"lf", "first\nsecond"},
+		{"cr", "first\rsecond"},
+		{"mixed line breaks", "a\r\n\r\nb\n\nc\r\rd\n\r"},
+		{"tab", "a\tb\t\t c\t"},
+		{"rtf delimiters", "{braces} and \\backslash\\ \\par {\\rtf1}"},
+		{"spaces", "  leading and trailing  "},
+		{"code page", "café naïve €"},
+		{"not in code page", "中文 Ж αβ"},
+		{"non bmp", "emoji \U0001F600 and \U00010348"},
+		{"trailing crlf", "last line\r\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rtf, err := EncodeText([]byte(test.text))
+			if err != nil {
+				t.Fatalf("encode failed: %v", err)
+			}
+
+			if got := convertRtf(t, rtf, "text"); got != test.text {
+				t.Fatalf("round trip mismatch:\n got %q\nwant %q\n%s", got, test.text, rtf)
+			}
+		})
+	}
+}
@@ -0,0 +1,425 @@
+/**
+ * extracts the text of a RTF document that does not encapsulate a plain text document (plain RTF created by Word, WordPad, etc)
+ */
+
+package rtfconverter
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+type rtfTextNativeInterpreter struct {
+	// the converted document; content is the document or the content of the current table cell
+	document    *bufio.Writer
+	content     *bufio.Writer
+	rtfEncoding string
+	fontTable   map[int]*rtfFontTableItem
+	stylesheet  rtfStylesheet
+
+	// the converter options
+	options rtfOptions
+
+	// the formatting of the parsed group; the hidden text is not extracted and the all caps text is extracted in upper case
+	formatting rtfFormattingStack
+
+	// \'HH bytes are collected and decoded together, so the multibyte code pages are decoded correctly
+	pendingBytes []byte
+
+	// the high surrogate of a \uN pair, waiting for the low surrogate
+	pendingSurrogate rune
+
+	// the field of the last \fldinst, waiting for its \fldrslt
+	pendingField *RtfField
+
+	// each opened \fldrslt group
+	fieldResults []*rtfTextFieldResult
+
+	// the table is written when it ends; the content of the current cell is written in cellContent
+	table       rtfTableBuilder
+	tableOpened bool
+	cellContent bytes.Buffer
+
+	// the list definitions; the item number is written when the paragraph text starts
+	listTable        map[int]RtfList
+	numbering        rtfListNumbering
+	paragraphStarted bool
+}
+
+/**
+ * an opened \fldrslt group: the url of the link and the beginning of the link text (the text is kept only while it
+ * may be the url)
+ */
+type rtfTextFieldResult struct {
+	url  string
+	text strings.Builder
+}
+
+/**
+ * the url follows the link text, unless the text is the url
+ */
+func (r *rtfTextFieldResult) urlSuffix() string {
+	if r.url == "" || strings.HasPrefix(r.url, "#") {
+		return ""
+	}
+
+	text := strings.TrimSpace(r.text.String())
+	if text == r.url || "mailto:"+text == r.url {
+		return ""
+	}
+	return " <" + r.url + ">"
+}
+
+func (p *rtfTextNativeInterpreter) Parse(rtfObj RtfStructure) ([]byte, error) {
+	buffer := bytes.Buffer{}
+
+	if err := parseTree(p, rtfObj, &buffer); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (p *rtfTextNativeInterpreter) startDocument(w io.Writer) {
+	p.document = bufio.NewWriter(w)
+	p.content = p.document
+	p.rtfEncoding = p.options.defaultEncoding
+	p.formatting = newRtfFormattingStack()
+}
+
+func (p *rtfTextNativeInterpreter) endDocument() error {
+	p.flushText()
+	p.endTable()
+
+	return p.content.Flush()
+}
+
+/**
+ * detect the rtf element from structure and decide the parser
+ */
+func (p *rtfTextNativeInterpreter) parseElement(item Element) {
+	if symbol, ok := item.(*ControlSymbol); !ok || symbol.GetSymbol() != "'" {
+		// the \'HH sequence ended
+		p.flushText()
+	}
+
+	switch item.(type) {
+	case *Group:
+		p.parseGroup(item.(*Group))
+	case *ControlSymbol:
+		p.parseControlSymbol(item.(*ControlSymbol))
+	case *ControlWord:
+		p.parseControlWord(item.(*ControlWord))
+	case *Text:
+		p.parseText(item.(*Text))
+	}
+}
+
+func (p *rtfTextNativeInterpreter) parseGroup(item *Group) {
+	walkGroup(p, item)
+}
+
+/**
+ * the font table, the stylesheet, the list tables and the field instructions are collected; the destinations
+ * without document text (\*\destination, info, pict, etc) are skipped
+ */
+func (p *rtfTextNativeInterpreter) groupAction(item *Group) rtfGroupAction {
+	if item.IsFontTable() || item.IsStylesheet() || item.IsFieldInstruction() || item.IsListtables() || item.IsListOverrideTable() ||
+		item.IsParagraphNumbering() {
+		return rtfGroupCollect
+	}
+
+	if item.IsSkippedDestination() {
+		return rtfGroupSkip
+	}
+
+	return rtfGroupWalk
+}
+
+func (p *rtfTextNativeInterpreter) parseCollectedGroup(item *Group) {
+	if item.IsFontTable() {
+		p.fontTable = extractFontTable(item)
+	} else if item.IsStylesheet() {
+		p.stylesheet = newRtfStylesheet(extractStyles(item, p.rtfEncoding))
+	} else if item.IsFieldInstruction() {
+		field := ParseFieldInstruction(decodeGroupText(item, p.rtfEncoding))
+		p.pendingField = &field
+	} else if item.IsListtables() {
+		p.listTable = extractListTable(item)
+	} else if item.IsListOverrideTable() {
+		p.numbering.lists = extractListOverrideTable(item, p.listTable)
+	} else if item.IsParagraphNumbering() && p.formatting.current.list <= 0 {
+		// the numbering of the old readers; \lsN is used if the paragraph has both
+		levelIdx, level := extractParagraphNumbering(item)
+		p.numbering.setLevel(rtfParagraphNumberingList, levelIdx, level)
+		p.formatting.current.list = rtfParagraphNumberingList
+		p.formatting.current.listLevel = levelIdx
+	}
+}
+
+func (p *rtfTextNativeInterpreter) startGroup(item *Group) {
+	p.flushText()
+	p.formatting.push()
+
+	if item.IsFieldResult() {
+		result := &rtfTextFieldResult{}
+		if p.pendingField != nil {
+			result.url = p.pendingField.URL()
+		}
+		p.fieldResults = append(p.fieldResults, result)
+		p.pendingField = nil
+	}
+}
+
+func (p *rtfTextNativeInterpreter) endGroup(item *Group) {
+	p.flushText()
+
+	if item.IsFieldResult() && len(p.fieldResults) > 0 {
+		// the url of a link follows the link text: text <url>
+		result := p.fieldResults[len(p.fieldResults)-1]
+		p.fieldResults = p.fieldResults[:len(p.fieldResults)-1]
+		if suffix := result.urlSuffix(); suffix != "" {
+			p.writeText(suffix)
+		}
+	}
+
+	p.formatting.pop()
+}
+
+func (p *rtfTextNativeInterpreter) parseControlSymbol(item *ControlSymbol) {
+	switch item.GetSymbol() {
+	case "'":
+		// convert the string, reprezenting an hex number to a byte
+		v, err := strconv.ParseUint(item.GetParameter(), 16, 8)
+		if err == nil {
+			p.pendingBytes = append(p.pendingBytes, byte(v))
+		}
+	case "~":
+		// non breaking space
+		p.writeText(" ")
+	case "_":
+		// non breaking hyphen
+		p.writeText("-")
+	case "{", "}", "\\":
+		p.writeText(item.GetSymbol())
+	}
+}
+
+func (p *rtfTextNativeInterpreter) parseControlWord(item *ControlWord) {
+	if p.table.parseControlWord(item) || p.formatting.current.parseControlWord(item, p.stylesheet) {
+		// a table definition word or a formatting word
+		return
+	}
+
+	switch item.GetWord() {
+	case "ansi", "mac", "pc", "pca":
+		p.rtfEncoding, _ = GetEncodingFromCodepage(item.GetWord())
+	case "ansicpg":
+		if item.GetIntParameter() > 0 {
+			if encoding, err := GetEncodingFromCodepage(item.GetParameter()); err == nil {
+				p.rtfEncoding = encoding
+			}
+		}
+	case "par", "sect", "page", "nestrow":
+		p.writeBreak()
+		p.paragraphStarted = false
+	case "line":
+		p.writeBreak()
+	case "tab", "nestcell":
+		p.writeText("\t")
+	case "cell":
+		p.endTableCell()
+	case "row":
+		p.endTableRow()
+	case "u":
+		p.parseUnicode(item)
+	case "lquote", "rquote":
+		p.writeText("'")
+	case "ldblquote", "rdblquote":
+		p.writeText("\"")
+	case "bullet":
+		p.writeText("\u2022")
+	case "endash":
+		p.writeText("-")
+	case "emdash":
+		p.writeText("--")
+	case "enspace", "emspace", "qmspace":
+		p.writeText(" ")
+	}
+}
+
+/**
+ * \uN - the replacement chars (\ucN) are already skipped by the tokenizer
+ * the chars outside the BMP are written as an utf-16 surrogate pair (2 \uN control words)
+ */
+func (p *rtfTextNativeInterpreter) parseUnicode(item *ControlWord) {
+	r, err := RuneFromUnicodeParameter(item.GetParameter())
+	if err != nil {
+		return
+	}
+
+	if r >= 0xd800 && r < 0xdc00 {
+		p.pendingSurrogate = r
+		return
+	}
+
+	if p.pendingSurrogate != 0 {
+		r = utf16.DecodeRune(p.pendingSurrogate, r)
+		p.pendingSurrogate = 0
+	}
+
+	p.writeText(p.formatting.current.displayText(string(r)))
+}
+
+func (p *rtfTextNativeInterpreter) parseText(item *Text) {
+	t, _ := ConvertToUtf8(UnescapeRtfText(item.GetContent()), p.currentEncoding())
+	p.writeText(p.formatting.current.displayText(string(t)))
+}
+
+/**
+ * the text is encoded with the charset of the current font, or with the document code page
+ */
+func (p *rtfTextNativeInterpreter) currentEncoding() string {
+	if fItem, ok := p.fontTable[p.formatting.current.font]; ok && fItem.charsetIndex > 2 {
+		if encoding, err := GetEncodingFromCharset(fItem.charsetIndex); err == nil && encoding != "" {
+			return encoding
+		}
+	}
+	return p.rtfEncoding
+}
+
+/**
+ * decode the collected \'HH bytes
+ */
+func (p *rtfTextNativeInterpreter) flushText() {
+	if len(p.pendingBytes) == 0 {
+		return
+	}
+
+	t, _ := ConvertToUtf8(p.pendingBytes, p.currentEncoding())
+	p.pendingBytes = nil
+	p.writeText(p.formatting.current.displayText(string(t)))
+}
+
+/**
+ * write the paragraph text; the list item number is written before the first text of the paragraph
+ */
+func (p *rtfTextNativeInterpreter) writeText(text string) {
+	if p.formatting.current.hidden {
+		return
+	}
+	p.prepareTable()
+
+	if !p.paragraphStarted {
+		p.paragraphStarted = true
+		p.writeListNumber()
+	}
+
+	if n := len(p.fieldResults); n > 0 {
+		if result := p.fieldResults[n-1]; result.url != "" && result.text.Len() <= len(result.url) {
+			result.text.WriteString(text)
+		}
+	}
+
+	p.content.WriteString(text)
+}
+
+/**
+ * write a line break (\par, \line); an empty paragraph has no list item number
+ */
+func (p *rtfTextNativeInterpreter) writeBreak() {
+	if p.formatting.current.hidden {
+		return
+	}
+	p.prepareTable()
+
+	if !p.paragraphStarted && p.formatting.current.list == 0 {
+		p.numbering.interrupt()
+	}
+
+	p.content.WriteString("\r\n")
+}
+
+/**
+ * the paragraph in a list starts with the indent of the level and the item number (or bullet)
+ */
+func (p *rtfTextNativeInterpreter) writeListNumber() {
+	list, levelIdx := p.formatting.current.list, p.formatting.current.listLevel
+	if list == 0 {
+		p.numbering.interrupt()
+		return
+	}
+	if levelIdx < 0 {
+		levelIdx = 0
+	}
+
+	number := formatListNumber(p.numbering.level(list, levelIdx), p.numbering.nextNumber(list, levelIdx))
+
+	p.content.WriteString(strings.Repeat("    ", levelIdx))
+	if number != "" {
+		p.content.WriteString(number)
+		p.content.WriteString(" ")
+	}
+}
+
+/**
+ * a paragraph in a table is written in the current cell; the table ends (and it is written) when a paragraph
+ * that is not in the table is written
+ */
+func (p *rtfTextNativeInterpreter) prepareTable() {
+	if p.formatting.current.inTable {
+		if !p.tableOpened {
+			p.content = bufio.NewWriter(&p.cellContent)
+			p.tableOpened = true
+		}
+	} else if p.tableOpened {
+		p.endTable()
+	}
+}
+
+/**
+ * \cell ends the content of the cell; a \cell without \intbl is in a table too
+ */
+func (p *rtfTextNativeInterpreter) endTableCell() {
+	p.formatting.current.inTable = true
+	p.prepareTable()
+
+	p.content.Flush()
+	p.table.endCell(p.cellContent.String())
+	p.cellContent.Reset()
+	p.paragraphStarted = false
+}
+
+func (p *rtfTextNativeInterpreter) endTableRow() {
+	p.formatting.current.inTable = true
+	p.prepareTable()
+
+	// the content after the last cell of the row is not in a cell
+	p.content.Flush()
+	p.cellContent.Reset()
+	p.table.endRow()
+}
+
+func (p *rtfTextNativeInterpreter) endTable() {
+	if !p.tableOpened {
+		return
+	}
+
+	p.content.Flush()
+	if strings.TrimSpace(p.cellContent.String()) != "" {
+		// the last cell was not ended with \cell
+		p.table.endCell(p.cellContent.String())
+	}
+	p.cellContent.Reset()
+
+	p.content = p.document
+	p.tableOpened = false
+
+	if !p.table.isEmpty() {
+		p.content.WriteString(textTable(p.table.table()))
+	}
+}
@@ -0,0 +1,70 @@
+package rtfconverter
+
+import (
+	"strings"
+	"testing"
+)
+
+const testListTable = "{\\*\\listtable{\\list\\listtemplateid1{\\listlevel\\levelnfc0\\levelstartat1{\\leveltext\\'02\\'00.;}{\\levelnumbers\\'01;}}" +
+	"{\\listlevel\\levelnfc23\\levelstartat1{\\leveltext\\'01\\u8226 ?;}{\\levelnumbers;}}\\listid10}}" +
+	"{\\*\\listoverridetable{\\listoverride\\listid10\\listoverridecount0\\ls1}}"
+
+func TestTextNativeConverter(t *testing.T) {
+	tests := []struct {
+		name string
+		rtf  string
+		text string
+	}{
+		{
+			"paragraphs",
+			"{\\rtf1\\ansi\\deff0{\\fonttbl{\\f0 Arial;}}\\pard first\\par\\pard second\\line third\\par}",
+			"first\r\nsecond\r\nthird\r\n",
+		},
+		{
+			"escapes and code page",
+			"{\\rtf1\\ansi\\ansicpg1252 a\\{b\\}c\\\\d\\tab caf\\'e9 \\u8364? \\ldblquote q\\rdblquote\\~x\\par}",
+			"a{b}c\\d\tcafé € \"q\"\u00a0x\r\n",
+		},
+		{
+			"hidden and caps",
+			"{\\rtf1 shown {\\v hidden}{\\caps upper}\\par}",
+			"shown UPPER\r\n",
+		},
+		{
+			"list",
+			"{\\rtf1" + testListTable + "\\pard\\ls1 one\\par\\ls1 \\par\\ls1 two\\par\\pard\\ls1\\ilvl1 sub\\par\\pard after\\par}",
+			"1. one\r\n\r\n2. two\r\n    • sub\r\nafter\r\n",
+		},
+		{
+			"links",
+			"{\\rtf1 {\\field{\\*\\fldinst HYPERLINK \"http://x.test/\"}{\\fldrslt site}} " +
+				"{\\field{\\*\\fldinst HYPERLINK \"http://x.test/\"}{\\fldrslt http://x.test/}} " +
+				"{\\field{\\*\\fldinst HYPERLINK \"mailto:a@x.test\"}{\\fldrslt a@x.test}} " +
+				"{\\field{\\*\\fldinst HYPERLINK \\\\l \"top\"}{\\fldrslt up}}\\par}",
+			"site <http://x.test/> http://x.test/ a@x.test up\r\n",
+		},
+		{
+			"table",
+			"{\\rtf1\\trowd\\cellx1000\\cellx2000\\pard\\intbl a\\cell b\\cell\\row\\trowd\\cellx1000\\cellx2000\\pard\\intbl c\\cell dd\\cell\\row\\pard after\\par}",
+			"| a   | b   |\r\n|-----|-----|\r\n| c   | dd  |\r\nafter\r\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := convertRtf(t, []byte(test.rtf), "text"); got != test.text {
+				t.Fatalf("got %q, expected %q", got, test.text)
+			}
+
+			// the stream conversion returns the same text
+			c := NewConverter()
+			result, err := c.ConvertReader(strings.NewReader(test.rtf), "text")
+			if err != nil {
+				t.Fatalf("stream conversion failed: %v", err)
+			}
+			if string(result) != test.text {
+				t.Fatalf("stream conversion: got %q, expected %q", result, test.text)
+			}
+		})
+	}
+}
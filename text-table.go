@@ -0,0 +1,80 @@
+/**
+ * write the tables rebuilt by rtfTableBuilder as text grids (markdown tables): the first row is the header and the
+ * columns are aligned
+ */
+
+package rtfconverter
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+/**
+ * a cell of the text grid: the first column of the cell and its content on a single line
+ */
+type textTableCell struct {
+	column  int
+	content string
+}
+
+/**
+ * the cell contents are already converted to text; the content of a merged cell is written in its first column
+ * each row keeps only its own cells; the number of columns is bounded by rtfTableBuilder (rtfTableMaxColumns)
+ */
+func textTable(t *rtfTable) string {
+	if t.columnCount == 0 {
+		return ""
+	}
+
+	grid := make([][]textTableCell, len(t.rows))
+	widths := make([]int, t.columnCount)
+	for i := range widths {
+		// the minimum width of the header separator
+		widths[i] = 3
+	}
+
+	for ri, row := range t.rows {
+		for _, cell := range row {
+			if cell.hidden || cell.column >= t.columnCount {
+				continue
+			}
+
+			// a cell is written on a single line
+			content := strings.Join(strings.Fields(cell.content), " ")
+			content = strings.ReplaceAll(content, "|", "\\|")
+
+			grid[ri] = append(grid[ri], textTableCell{column: cell.column, content: content})
+			if width := utf8.RuneCountInString(content); width > widths[cell.column] {
+				widths[cell.column] = width
+			}
+		}
+	}
+
+	result := strings.Builder{}
+	for ri, row := range grid {
+		next := 0
+		for ci, width := range widths {
+			content := ""
+			if next < len(row) && row[next].column == ci {
+				content = row[next].content
+				next++
+			}
+
+			result.WriteString("| ")
+			result.WriteString(content)
+			result.WriteString(strings.Repeat(" ", width-utf8.RuneCountInString(content)+1))
+		}
+		result.WriteString("|\r\n")
+
+		if ri == 0 {
+			for _, width := range widths {
+				result.WriteString("|")
+				result.WriteString(strings.Repeat("-", width+2))
+			}
+			result.WriteString("|\r\n")
+		}
+	}
+
+	return result.String()
+}
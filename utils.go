@@ -1,8 +1,12 @@
 package rtfconverter
 
 import (
+	"bytes"
 	"regexp"
+	"strconv"
 	"errors"
+	"fmt"
+	"unicode/utf16"
     "golang.org/x/text/encoding"
     "golang.org/x/text/encoding/charmap"
     "golang.org/x/text/encoding/japanese"
@@ -135,73 +139,152 @@ func ConvertToUtf8(b []byte, srcEncoding string) ([]byte, error) {
   var (
     result []byte
     err error
-    dec  *encoding.Decoder
   )
 
   //fmt.Println("Decoding: ", string(b))
 
-  switch (srcEncoding) {
+  if enc := getEncoding(srcEncoding); enc != nil {
+    result, err = enc.NewDecoder().Bytes(b)
+  } else {
+    result = b[0:]
+  }
+
+  return result, err
+}
+
+/**
+ * return the encoding for an encoding name (see rtfEncodeCodePageMap); nil if the encoding is not supported
+ */
+func getEncoding(name string) encoding.Encoding {
+  switch (name) {
     case "MAC": // [MacRoman]: Macintosh
-        dec = charmap.Macintosh.NewDecoder()
+        return charmap.Macintosh
     case "CP437": // United States IBM
-        dec = charmap.CodePage437.NewDecoder()
+        return charmap.CodePage437
     case "ASMO-708": // also [ISO-8859-6][ARABIC] Arabic
-        dec = charmap.ISO8859_6.NewDecoder()
+        return charmap.ISO8859_6
     case "CP819":   // Windows 3.1 (US and Western Europe)
-        dec = charmap.ISO8859_1.NewDecoder()
+        return charmap.ISO8859_1
     case "CP850":   // IBM multilingual
-        dec = charmap.CodePage850.NewDecoder()
+        return charmap.CodePage850
     case "CP852":   // Eastern European
-        dec = charmap.CodePage852.NewDecoder()
+        return charmap.CodePage852
     case "CP860":   // Portuguese
-        dec = charmap.CodePage860.NewDecoder()
+        return charmap.CodePage860
     case "CP862":   // Hebrew
-        dec = charmap.CodePage862.NewDecoder()
+        return charmap.CodePage862
     case "CP863":   // French Canadian
-        dec = charmap.CodePage863.NewDecoder()
+        return charmap.CodePage863
     case "CP864":   // Arabic
     case "CP865":   // Norwegian
-        dec = charmap.CodePage865.NewDecoder()
+        return charmap.CodePage865
     case "CP866":   // Soviet Union
-        dec = charmap.CodePage866.NewDecoder()
+        return charmap.CodePage866
     case "CP874":   // Thai
-        dec = charmap.Windows874.NewDecoder()
+        return charmap.Windows874
     case "CP932":   // Japanese
-        dec = japanese.ShiftJIS.NewDecoder()
+        return japanese.ShiftJIS
     case "CP936":   // Simplified Chinese
-        dec = simplifiedchinese.GBK.NewDecoder()
+        return simplifiedchinese.GBK
     case "CP949":   // Korean
-        dec = korean.EUCKR.NewDecoder();
+        return korean.EUCKR
     case "CP950":   // Traditional Chinese
-        dec = traditionalchinese.Big5.NewDecoder();
+        return traditionalchinese.Big5
     case "CP1250":  // Windows 3.1 (Eastern European)
-        dec =  charmap.Windows1250.NewDecoder()
+        return charmap.Windows1250
     case "CP1251":  // Windows 3.1 (Cyrillic)
-        dec =  charmap.Windows1251.NewDecoder()
+        return charmap.Windows1251
     case "CP1252":  // Western European
-        dec =  charmap.Windows1252.NewDecoder()
+        return charmap.Windows1252
     case "CP1253":  // Greek
-        dec =  charmap.Windows1253.NewDecoder()
+        return charmap.Windows1253
     case "CP1254":  // Turkish
-        dec =  charmap.Windows1254.NewDecoder()
+        return charmap.Windows1254
     case "CP1255":  // Hebrew
-        dec =  charmap.Windows1255.NewDecoder()
+        return charmap.Windows1255
     case "CP1256":  // Arabic
-        dec =  charmap.Windows1256.NewDecoder()
+        return charmap.Windows1256
     case "CP1257":  // Baltic
-        dec =  charmap.Windows1257.NewDecoder()
+        return charmap.Windows1257
     case "CP1258":  // Vietnamese
-        dec =  charmap.Windows1258.NewDecoder()
+        return charmap.Windows1258
     case "CP1361":   // Johab
-        dec = korean.EUCKR.NewDecoder()
+        return korean.EUCKR
 
   }
 
-  if dec != nil {
-    result, err = dec.Bytes(b)
-  } else {
-    result = b[0:]
-  }
+  return nil
+}
 
-  return result, err
-}
\ No newline at end of file
+/**
+ * the tokenizer keeps the escaped chars (\{, \}, \\) inside the text tokens; remove the escape char
+ */
+func UnescapeRtfText(b []byte) []byte {
+	if bytes.IndexByte(b, '\\') < 0 {
+		return b
+	}
+
+	result := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		if b[i] == '\\' && i+1 < len(b) {
+			i++
+		}
+		result = append(result, b[i])
+	}
+	return result
+}
+
+/**
+ * \uN parameter is a signed 16-bit number; unicode values greater than 32767 are expressed as negative numbers
+ */
+func RuneFromUnicodeParameter(parameter string) (rune, error) {
+	v, err := strconv.Atoi(parameter)
+	if err != nil {
+		return 0, err
+	}
+	if v < 0 {
+		v += 65536
+	}
+	return rune(v), nil
+}
+
+/**
+ * escape a text for a RTF document: {, } and \ are escaped, the non ASCII chars are written as \'HH when the encoding
+ * has the char, or as \uN followed by a ? replacement char (\uc1) otherwise
+ */
+func EscapeRtfText(text string, encodingName string) []byte {
+	var encoder *encoding.Encoder
+	if enc := getEncoding(encodingName); enc != nil {
+		encoder = enc.NewEncoder()
+	}
+
+	result := bytes.Buffer{}
+	for _, r := range text {
+		switch {
+		case r == '{' || r == '}' || r == '\\':
+			result.WriteByte('\\')
+			result.WriteRune(r)
+		case r < 0x80:
+			result.WriteRune(r)
+		default:
+			if encoder != nil {
+				if b, err := encoder.String(string(r)); err == nil {
+					for i := 0; i < len(b); i++ {
+						fmt.Fprintf(&result, "\\'%02x", b[i])
+					}
+					continue
+				}
+			}
+
+			// the chars outside the BMP are written as an utf-16 surrogate pair
+			units := []rune{r}
+			if r1, r2 := utf16.EncodeRune(r); r1 != 0xfffd || r2 != 0xfffd {
+				units = []rune{r1, r2}
+			}
+			for _, u := range units {
+				fmt.Fprintf(&result, "\\u%d?", int16(u))
+			}
+		}
+	}
+	return result.Bytes()
+}